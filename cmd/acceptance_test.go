@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/r1cm3d/authorizer/internal"
+)
+
+var update = flag.Bool("update", false, "regenerate the golden files of data directory")
+
+// TestAcceptance runs each input of data directory through authorize and compares its output with the golden file
+// of the same name plus .exp suffix. With -update, the golden files are written instead.
+func TestAcceptance(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("..", "data", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range inputs {
+		if strings.HasSuffix(path, ".exp") {
+			continue
+		}
+		path := path
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			got := authorizeFile(t, path, 1)
+			if *update {
+				if err := os.WriteFile(path+".exp", got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(path + ".exp")
+			if err != nil {
+				t.Fatalf("%v, run with -update to create it", err)
+			}
+			if !bytes.Equal(want, got) {
+				t.Errorf("%s output differs from %s.exp:\n%s", path, path, diff(string(want), string(got)))
+			}
+			for _, workers := range []int{2, 8} {
+				if par := authorizeFile(t, path, workers); !bytes.Equal(got, par) {
+					t.Errorf("%s output with %d workers differs:\n%s", path, workers, diff(string(got), string(par)))
+				}
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	cases := []struct {
+		name string
+		want string
+		got  string
+		out  string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"changed line", "a\nb\n", "a\nc\n", "line 2\n- b\n+ c\n"},
+		{"missing line", "a\nb\n", "a\n", "line 2\n- b\n+ \n"},
+		{"extra line", "a\n", "a\nb\n", "line 2\n- \n+ b\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := diff(c.want, c.got); got != c.out {
+				t.Errorf("%s, want: %q, got: %q", c.name, c.out, got)
+			}
+		})
+	}
+}
+
+// authorizeFile returns the output of the input file processed by the given number of workers.
+func authorizeFile(t *testing.T, path string, workers int) []byte {
+	t.Helper()
+	in, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+
+	var out bytes.Buffer
+	if err := authorize(internal.NewPipeline(workers, internal.NewTimeline), in, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	return out.Bytes()
+}
+
+// diff returns the lines that differ between want and got, prefixed by - and + respectively.
+// A line missing on either side is shown empty.
+func diff(want, got string) string {
+	w, g := strings.Split(want, "\n"), strings.Split(got, "\n")
+	var b strings.Builder
+	for i := 0; i < len(w) || i < len(g); i++ {
+		var wl, gl string
+		if i < len(w) {
+			wl = w[i]
+		}
+		if i < len(g) {
+			gl = g[i]
+		}
+		if wl != gl {
+			fmt.Fprintf(&b, "line %d\n- %s\n+ %s\n", i+1, wl, gl)
+		}
+	}
+
+	return b.String()
+}
@@ -2,21 +2,257 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"flag"
 	"fmt"
 	"github.com/r1cm3d/authorizer/internal"
+	"io"
+	"net"
 	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
 )
 
 // Example
 // ./authorize < data/operations
+// ./authorize --config config.json < data/operations
+// ./authorize --config config.json --print-config
+// ./authorize --workers 8 < data/operations
+// ./authorize --explain < data/operations
+// ./authorize --wal authorizer.wal --wal-sync batch < data/operations
+// ./authorize --restore-snapshot authorizer.snapshot --snapshot authorizer.snapshot < data/operations
+// ./authorize serve --addr :8080 --config config.json
+// ./authorize serve --addr :8080 --grpc-addr :9090
+// ./authorize daemon --socket /tmp/authorizer.sock
+// ./authorize generate --accounts 100 --events 100000 --seed 42 | ./authorize --workers 8
 func main() {
-	scanner := bufio.NewScanner(os.Stdin)
-	timeline := internal.NewTimeline()
-	fmt.Println()
-	for scanner.Scan() {
-		event := internal.Parse(scanner.Text())
-		timeline.Process(event)
-		fmt.Println(timeline.Last())
-	}
-	fmt.Println()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve":
+			serve(os.Args[2:])
+			return
+		case "daemon":
+			daemon(os.Args[2:])
+			return
+		case "generate":
+			generate(os.Args[2:])
+			return
+		}
+	}
+
+	configPath := flag.String("config", "", "path of a JSON file with the rules thresholds and the timeline settings")
+	printConfig := flag.Bool("print-config", false, "print the active configuration and exit")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of workers processing accounts in parallel")
+	walPath := flag.String("wal", "", "path of a write-ahead log of the input events, replayed on startup")
+	walSync := flag.String("wal-sync", "always", "when the write-ahead log is flushed to disk: always, batch or never")
+	restorePath := flag.String("restore-snapshot", "", "path of a snapshot to start from, before the write-ahead log is replayed")
+	snapshotPath := flag.String("snapshot", "", "path where a snapshot is written on exit, which resets the write-ahead log")
+	explain := flag.Bool("explain", false, "add the evidence of each violation to the output, overriding the config")
+	flag.Parse()
+
+	cfg := loadConfig(*configPath)
+	if *explain {
+		cfg.Explain = true
+	}
+	if *printConfig {
+		fmt.Println(cfg)
+		return
+	}
+
+	pipeline := internal.NewPipeline(*workers, func() internal.Timeline {
+		return internal.NewTimelineWithConfig(cfg)
+	})
+	// The WAL records before the sequence number of the snapshot are already in it.
+	var from uint64
+	if *restorePath != "" {
+		snapshot, err := internal.LoadSnapshot(*restorePath, cfg)
+		if err != nil {
+			fail(err)
+		}
+		pipeline.Restore(snapshot)
+		from = snapshot.WALSequence
+	}
+	var wal *internal.WAL
+	if *walPath != "" {
+		policy, err := internal.ParseSyncPolicy(*walSync)
+		if err != nil {
+			fail(err)
+		}
+		if wal, err = internal.OpenWAL(*walPath, policy, from, pipeline); err != nil {
+			fail(err)
+		}
+		defer wal.Close()
+		pipeline.Log(wal)
+	}
+
+	if err := authorize(pipeline, os.Stdin, os.Stdout); err != nil {
+		fail(err)
+	}
+
+	if *snapshotPath != "" {
+		if err := internal.SaveSnapshot(*snapshotPath, pipeline.Snapshot(cfg)); err != nil {
+			fail(err)
+		}
+		if wal != nil {
+			if err := wal.Reset(); err != nil {
+				fail(err)
+			}
+		}
+	}
+}
+
+// serve runs the HTTP API, and the gRPC API when it has an address, until an interrupt or termination signal.
+// Then it drains the in-flight requests. Both APIs share the same timelines.
+// See internal.Server and internal.GRPCServer for the endpoints.
+func serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", ":8080", "address where the HTTP API listens")
+	configPath := flags.String("config", "", "path of a JSON file with the rules thresholds and the timeline settings")
+	grpcAddr := flags.String("grpc-addr", "", "address where the gRPC API listens, it is disabled when empty")
+	drain := flags.Duration("drain", 10*time.Second, "how long in-flight requests are waited for on shutdown")
+	flags.Parse(args)
+
+	cfg := loadConfig(*configPath)
+	authorizer := concurrentAuthorizer(cfg)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	type server struct {
+		addr  string
+		serve func(net.Listener) error
+	}
+	servers := []server{{*addr, func(l net.Listener) error {
+		return internal.NewServer(authorizer).Serve(ctx, l, *drain)
+	}}}
+	if *grpcAddr != "" {
+		servers = append(servers, server{*grpcAddr, func(l net.Listener) error {
+			return internal.NewGRPCServer(authorizer).Serve(ctx, l, *drain)
+		}})
+	}
+
+	errs := make(chan error, len(servers))
+	for _, srv := range servers {
+		l, err := net.Listen("tcp", srv.addr)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Fprintf(os.Stderr, "listening on %s\n", l.Addr())
+		go func(serve func(net.Listener) error) {
+			errs <- serve(l)
+		}(srv.serve)
+	}
+	for range servers {
+		if err := <-errs; err != nil {
+			fail(err)
+		}
+	}
+}
+
+// daemon serves the line protocol of the standard input on a Unix socket until an interrupt or termination signal.
+// Then it waits for the clients to close their connections. All connections share the same timelines.
+// See internal.Daemon for the protocol.
+func daemon(args []string) {
+	flags := flag.NewFlagSet("daemon", flag.ExitOnError)
+	socket := flags.String("socket", "authorizer.sock", "path of the Unix socket where the daemon listens")
+	configPath := flags.String("config", "", "path of a JSON file with the rules thresholds and the timeline settings")
+	drain := flags.Duration("drain", 10*time.Second, "how long open connections are waited for on shutdown")
+	flags.Parse(args)
+
+	authorizer := concurrentAuthorizer(loadConfig(*configPath))
+	// A socket left behind by a crash would make Listen fail. Other kinds of file are never removed.
+	if info, err := os.Stat(*socket); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if _, err := net.Dial("unix", *socket); err == nil {
+			fail(fmt.Errorf("%s: another daemon is listening", *socket))
+		}
+		os.Remove(*socket)
+	}
+	l, err := net.Listen("unix", *socket)
+	if err != nil {
+		fail(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	fmt.Fprintf(os.Stderr, "listening on %s\n", l.Addr())
+	if err := internal.NewDaemon(authorizer).Serve(ctx, l, *drain); err != nil {
+		fail(err)
+	}
+}
+
+// generate writes a synthetic input stream into the standard output. See internal.GeneratorConfig for the flags.
+func generate(args []string) {
+	gc := internal.DefaultGeneratorConfig()
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	flags.Int64Var(&gc.Seed, "seed", gc.Seed, "seed of the random numbers, the same seed generates the same stream")
+	flags.IntVar(&gc.Accounts, "accounts", gc.Accounts, "number of accounts, events have account-id when it is greater than one")
+	flags.IntVar(&gc.Events, "events", gc.Events, "number of transactions, including the ones of fraud patterns")
+	start := flags.String("start", gc.Start.Format(time.RFC3339), "RFC-3339 datetime of the first transaction")
+	flags.DurationVar(&gc.Interval, "interval", gc.Interval, "mean interval between two transactions of any account")
+	flags.IntVar(&gc.Limit, "limit", gc.Limit, "available limit of each account")
+	flags.IntVar(&gc.Merchants, "merchants", gc.Merchants, "number of merchants")
+	flags.Float64Var(&gc.MerchantSkew, "merchant-skew", gc.MerchantSkew, "Zipf exponent of the merchants distribution, greater than one, or zero for uniform")
+	amounts := flags.String("amounts", string(gc.Amounts), "distribution of the amounts: uniform or exponential")
+	flags.IntVar(&gc.MeanAmount, "mean-amount", gc.MeanAmount, "mean amount of the transactions")
+	flags.Float64Var(&gc.Bursts, "bursts", gc.Bursts, "probability of a burst of transactions of an account within seconds")
+	flags.Float64Var(&gc.Duplicates, "duplicates", gc.Duplicates, "probability of a transaction repeated within seconds")
+	flags.Float64Var(&gc.Exhaustions, "exhaustions", gc.Exhaustions, "probability of a series of transactions exceeding the limit")
+	flags.Parse(args)
+
+	var err error
+	if gc.Start, err = time.Parse(time.RFC3339, *start); err != nil {
+		fail(err)
+	}
+	gc.Amounts = internal.AmountDistribution(*amounts)
+	if err := internal.Generate(os.Stdout, gc); err != nil {
+		fail(err)
+	}
+}
+
+// authorize is the entry point of the default mode: it writes the output of each input line of in, surrounded by
+// blank lines, into out. The pipeline state is kept, so it could be snapshotted afterwards.
+func authorize(pipeline *internal.Pipeline, in io.Reader, out io.Writer) error {
+	w := bufio.NewWriter(out)
+	fmt.Fprintln(w)
+	if err := pipeline.Run(in, w); err != nil {
+		w.Flush()
+		return err
+	}
+	fmt.Fprintln(w)
+
+	return w.Flush()
+}
+
+// concurrentAuthorizer returns the ConcurrentAuthorizer shared by the servers, whose timelines have the given Config.
+// It fails when the servers cannot answer each request right away, i.e. with the reorder late policy.
+func concurrentAuthorizer(cfg internal.Config) *internal.ConcurrentAuthorizer {
+	authorizer, err := internal.NewConcurrentAuthorizer(func() internal.Timeline {
+		return internal.NewTimelineWithConfig(cfg)
+	})
+	if err != nil {
+		fail(err)
+	}
+
+	return authorizer
+}
+
+// loadConfig returns the Config of the given path. When the path is empty, it returns the DefaultConfig.
+func loadConfig(path string) internal.Config {
+	if path == "" {
+		return internal.DefaultConfig()
+	}
+
+	cfg, err := internal.LoadConfig(path)
+	if err != nil {
+		fail(err)
+	}
+
+	return cfg
+}
+
+// fail prints the error to the standard error and exits with status 1.
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
 }
@@ -0,0 +1,100 @@
+package internal
+
+import "time"
+
+type (
+	// Authorizer routes each Event to the Timeline of its Account.
+	// Events without Account ID share the same Timeline, so a single Account input behaves as a plain Timeline.
+	// It is NOT thread safe and SHOULD NOT be used in concurrent environments.
+	Authorizer struct {
+		// timelines has one Timeline per Account ID.
+		timelines map[string]*Timeline
+		// newTimeline creates the Timeline of each new Account.
+		newTimeline func() Timeline
+		// last is the Timeline of the last processed Event.
+		last *Timeline
+		// now is the clock of the stream, the datetime of the latest Event of all Accounts, see Advance.
+		now time.Time
+	}
+)
+
+// NewAuthorizer creates a new Authorizer that calls newTimeline to create the Timeline of each new Account,
+// e.g. NewTimeline or a closure over NewTimelineWithConfig.
+func NewAuthorizer(newTimeline func() Timeline) *Authorizer {
+	return &Authorizer{
+		timelines:   make(map[string]*Timeline),
+		newTimeline: newTimeline,
+	}
+}
+
+// Process routes the Event to the Timeline of its Account, creating it when needed.
+// Before that, the authorizations of the Account that expired until the latest Event of all Accounts are released,
+// see Timeline.Expire.
+func (a *Authorizer) Process(ie Event) {
+	a.Advance(ie.time())
+	id := ie.accountID()
+	t, ok := a.timelines[id]
+	if !ok {
+		nt := a.newTimeline()
+		t = &nt
+		a.timelines[id] = t
+	}
+
+	t.Expire(a.now)
+	t.Process(ie)
+	a.last = t
+}
+
+// Advance moves the clock of the stream forward to now, e.g. the datetime of an Event processed by another Authorizer
+// of the same stream, so the authorizations of every Account expire by it, see Process.
+// Zero or past datetimes do not move it.
+func (a *Authorizer) Advance(now time.Time) {
+	if now.After(a.now) {
+		a.now = now
+	}
+}
+
+// Last returns the last TimelineEvent of the last processed Event.
+// It returns nil when no Event was processed.
+func (a *Authorizer) Last() *TimelineEvent {
+	if a.last == nil {
+		return nil
+	}
+
+	return a.last.Last()
+}
+
+// Decided returns the TimelineEvent decided by the last processed Event, see Timeline.Decided.
+// It returns nil when no Event was processed.
+func (a *Authorizer) Decided() []TimelineEvent {
+	if a.last == nil {
+		return nil
+	}
+
+	return a.last.Decided()
+}
+
+// Timeline returns the Timeline of the given Account ID and whether it exists.
+// Before that, its authorizations that expired until the latest Event of all Accounts are released, see Process.
+func (a *Authorizer) Timeline(id string) (*Timeline, bool) {
+	t, ok := a.timelines[id]
+	if ok {
+		t.Expire(a.now)
+	}
+
+	return t, ok
+}
+
+// Snapshot adds the TimelineSnapshot of each Account to the given map.
+func (a *Authorizer) Snapshot(accounts map[string]TimelineSnapshot) {
+	for id, t := range a.timelines {
+		accounts[id] = t.Snapshot()
+	}
+}
+
+// Restore creates the Timeline of the given Account ID from the TimelineSnapshot, replacing the existing one.
+func (a *Authorizer) Restore(id string, s TimelineSnapshot) {
+	t := a.newTimeline()
+	t.Restore(s)
+	a.timelines[id] = &t
+}
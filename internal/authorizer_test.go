@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAuthorizer_Process(t *testing.T) {
+	in := []Event{
+		{Account: &Account{ID: "alice", ActiveCard: true, AvailableLimit: 100}},
+		{Transaction: &Transaction{AccountID: "bob", Merchant: "Colorado Avalanche", Amount: 10, Time: trTime}},
+		{Account: &Account{ID: "bob", ActiveCard: true, AvailableLimit: 50}},
+		{Transaction: &Transaction{AccountID: "alice", Merchant: "Colorado Avalanche", Amount: 10, Time: trTime}},
+		{Transaction: &Transaction{AccountID: "bob", Merchant: "Colorado Avalanche", Amount: 10, Time: trTime}},
+		{Account: &Account{ID: "alice", ActiveCard: true, AvailableLimit: 350}},
+		{Account: &Account{ActiveCard: true, AvailableLimit: 10}},
+	}
+	want := []string{
+		`{"Account":{"account-id":"alice","active-card":true,"available-limit":100},"violations":[]}`,
+		`{"Account":{"account-id":"bob"},"violations":["Account-not-initialized"]}`,
+		`{"Account":{"account-id":"bob","active-card":true,"available-limit":50},"violations":[]}`,
+		`{"Account":{"account-id":"alice","active-card":true,"available-limit":90},"violations":[]}`,
+		`{"Account":{"account-id":"bob","active-card":true,"available-limit":40},"violations":[]}`,
+		`{"Account":{"account-id":"alice","active-card":true,"available-limit":90},"violations":["Account-already-initialized"]}`,
+		`{"Account":{"active-card":true,"available-limit":10},"violations":[]}`,
+	}
+
+	authorizer := NewAuthorizer(NewTimeline)
+	if got := authorizer.Last(); got != nil {
+		t.Errorf("want: nil, got: %v", got)
+	}
+	for i, ie := range in {
+		authorizer.Process(ie)
+		if got := authorizer.Last().String(); got != want[i] {
+			t.Errorf("event %d, want: %s, got: %s", i, want[i], got)
+		}
+	}
+
+	for id, n := range map[string]int{"alice": 3, "bob": 3, "": 1} {
+		timeline, ok := authorizer.Timeline(id)
+		if !ok {
+			t.Fatalf("timeline %q not found", id)
+		}
+		if got := len(timeline.Events()); got != n {
+			t.Errorf("timeline %q, want: %d events, got: %d", id, n, got)
+		}
+	}
+	if _, ok := authorizer.Timeline("carol"); ok {
+		t.Errorf("want timeline %q not found", "carol")
+	}
+}
+
+func TestAuthorizer_SingleAccount(t *testing.T) {
+	for _, in := range [][]Event{sfInput, aaiInput, hfInput, dtInput} {
+		authorizer := NewAuthorizer(NewTimeline)
+		timeline := NewTimeline()
+		for _, ie := range in {
+			authorizer.Process(ie)
+			timeline.Process(ie)
+			if want, got := timeline.Last(), authorizer.Last(); !reflect.DeepEqual(want, got) {
+				t.Errorf("want: %v, got: %v", want, got)
+			}
+		}
+	}
+}
+
+func TestAuthorizer_Process_HoldExpiry(t *testing.T) {
+	authorizer := NewAuthorizer(NewTimeline)
+	for _, ie := range []Event{
+		{Account: &Account{ID: "alice", ActiveCard: true, AvailableLimit: 100}},
+		{Transaction: &Transaction{ID: "hotel", AccountID: "alice", Merchant: "Calgary Flames", Amount: 70, Time: trTime}, Hold: true},
+		{Account: &Account{ID: "bob", ActiveCard: true, AvailableLimit: 100}},
+		{Transaction: &Transaction{AccountID: "bob", Merchant: "Edmonton Oilers", Amount: 10, Time: aeTime}},
+	} {
+		authorizer.Process(ie)
+	}
+
+	timeline, _ := authorizer.Timeline("alice")
+	want := Account{ID: "alice", ActiveCard: true, AvailableLimit: 100}
+	if got := timeline.State(); !reflect.DeepEqual(&want, got) {
+		t.Errorf("want: %+v, got: %+v", want, got)
+	}
+	if got := timeline.Last().Expired; got == nil || got.ID != "hotel" {
+		t.Errorf("want: hotel expired, got: %+v", got)
+	}
+}
@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type (
+	// ConcurrentAuthorizer is a thread safe Authorizer.
+	// Events of the same Account are processed one at a time, while Events of different Accounts are processed in
+	// parallel. Reads return copies, so they are never affected by Events processed later.
+	ConcurrentAuthorizer struct {
+		// mu guards timelines.
+		mu sync.RWMutex
+		// timelines has one lockedTimeline per Account ID.
+		timelines map[string]*lockedTimeline
+		// newTimeline creates the Timeline of each new Account.
+		newTimeline func() Timeline
+		// clock guards now.
+		clock sync.Mutex
+		// now is the datetime of the latest Event of all Accounts. The authorizations of each Account expire by it
+		// before its Timeline is read or written, see Timeline.Expire.
+		now time.Time
+	}
+	// lockedTimeline is a Timeline whose access is serialised by mu.
+	lockedTimeline struct {
+		mu       sync.Mutex
+		timeline Timeline
+	}
+)
+
+// NewConcurrentAuthorizer creates a new ConcurrentAuthorizer that calls newTimeline to create the Timeline of each
+// new Account, e.g. NewTimeline or a closure over NewTimelineWithConfig.
+// It returns an error wrapping ErrInvalidConfig when the late policy of the Timeline is LateReorder: Process answers
+// each Event right away, so it cannot buffer them.
+func NewConcurrentAuthorizer(newTimeline func() Timeline) (*ConcurrentAuthorizer, error) {
+	if p := newTimeline().latePolicy; p == LateReorder {
+		return nil, fmt.Errorf("%w: late-policy %s cannot answer each event right away", ErrInvalidConfig, p)
+	}
+
+	return &ConcurrentAuthorizer{
+		timelines:   make(map[string]*lockedTimeline),
+		newTimeline: newTimeline,
+	}, nil
+}
+
+// Process routes the Event to the Timeline of its Account, creating it when needed, and returns a copy of the
+// resulting TimelineEvent. It is safe to call it from many goroutines.
+func (a *ConcurrentAuthorizer) Process(ie Event) TimelineEvent {
+	now := a.advance(ie.time())
+	lt := a.timeline(ie.accountID())
+
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	lt.timeline.Expire(now)
+	lt.timeline.Process(ie)
+
+	return lt.timeline.Last().clone()
+}
+
+// Events returns a copy of the TimelineEvent retained in the Timeline of the given Account ID, see Timeline.Events.
+// It returns nil when the Account has no Timeline.
+func (a *ConcurrentAuthorizer) Events(id string) []TimelineEvent {
+	lt, ok := a.lookup(id)
+	if !ok {
+		return nil
+	}
+
+	now := a.advance(time.Time{})
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	lt.timeline.Expire(now)
+	events := lt.timeline.Events()
+	copies := make([]TimelineEvent, len(events))
+	for i, te := range events {
+		copies[i] = te.clone()
+	}
+
+	return copies
+}
+
+// State returns a copy of the current state of the given Account ID and whether it is initialized.
+func (a *ConcurrentAuthorizer) State(id string) (Account, bool) {
+	lt, ok := a.lookup(id)
+	if !ok {
+		return Account{}, false
+	}
+
+	now := a.advance(time.Time{})
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	lt.timeline.Expire(now)
+	if acc := lt.timeline.State(); acc != nil {
+		return *acc, true
+	}
+
+	return Account{}, false
+}
+
+// advance moves the clock of all Accounts forward to the given datetime and returns it.
+// Zero or past datetimes do not move it, so a zero one just returns the clock.
+func (a *ConcurrentAuthorizer) advance(at time.Time) time.Time {
+	a.clock.Lock()
+	defer a.clock.Unlock()
+	if at.After(a.now) {
+		a.now = at
+	}
+
+	return a.now
+}
+
+// lookup returns the lockedTimeline of the given Account ID and whether it exists.
+func (a *ConcurrentAuthorizer) lookup(id string) (*lockedTimeline, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	lt, ok := a.timelines[id]
+
+	return lt, ok
+}
+
+// timeline returns the lockedTimeline of the given Account ID, creating it when needed.
+func (a *ConcurrentAuthorizer) timeline(id string) *lockedTimeline {
+	if lt, ok := a.lookup(id); ok {
+		return lt
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	lt, ok := a.timelines[id]
+	if !ok {
+		lt = &lockedTimeline{timeline: a.newTimeline()}
+		a.timelines[id] = lt
+	}
+
+	return lt
+}
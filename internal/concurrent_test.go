@@ -0,0 +1,194 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentAuthorizer_Process(t *testing.T) {
+	const replicas = 16
+	// Each case has its own ConcurrentAuthorizer, since the Event of a case would expire the authorizations of another.
+	authorizers := make(map[string]*ConcurrentAuthorizer)
+
+	var wg sync.WaitGroup
+	for _, c := range processCases {
+		authorizer := newConcurrentAuthorizer(t)
+		authorizers[c.name] = authorizer
+		for r := 0; r < replicas; r++ {
+			wg.Add(1)
+			go func(id string, in []Event) {
+				defer wg.Done()
+				for _, ie := range in {
+					authorizer.Process(withAccountID(ie, id))
+				}
+			}(fmt.Sprintf("%s-%d", c.name, r), c.in)
+		}
+	}
+	wg.Wait()
+
+	for _, c := range processCases {
+		for r := 0; r < replicas; r++ {
+			id := fmt.Sprintf("%s-%d", c.name, r)
+			if got := withoutAccountID(authorizers[c.name].Events(id)); !reflect.DeepEqual(c.want, got) {
+				t.Errorf("%s, want: %v, got: %v", id, c.want, got)
+			}
+		}
+	}
+}
+
+func TestConcurrentAuthorizer_SameAccount(t *testing.T) {
+	const goroutines, increases = 32, 100
+	authorizer := newConcurrentAuthorizer(t)
+	authorizer.Process(Event{Account: &Account{ID: "alice", ActiveCard: true, AvailableLimit: 0}})
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < increases; i++ {
+				te := authorizer.Process(Event{Limit: &LimitChange{AccountID: "alice", Amount: 1, Operation: LimitIncrease}})
+				if te.hasViolation() {
+					t.Errorf("want no violation, got: %v", te.Violations)
+				}
+				authorizer.State("alice")
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := Account{ID: "alice", ActiveCard: true, AvailableLimit: goroutines * increases}
+	if got, ok := authorizer.State("alice"); !ok || got != want {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	if got := len(authorizer.Events("alice")); got != goroutines*increases+1 {
+		t.Errorf("want: %d events, got: %d", goroutines*increases+1, got)
+	}
+}
+
+func TestConcurrentAuthorizer_Copies(t *testing.T) {
+	authorizer := newConcurrentAuthorizer(t)
+	te := authorizer.Process(Event{Account: &Account{ActiveCard: true, AvailableLimit: 100}})
+	te.Account.AvailableLimit = 0
+	te.Violations = append(te.Violations, cardNotActive)
+
+	events := authorizer.Events("")
+	events[0].Account.ActiveCard = false
+
+	want := []TimelineEvent{{Event: Event{Account: &Account{ActiveCard: true, AvailableLimit: 100}}, Violations: []Violation{}}}
+	if got := authorizer.Events(""); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	if got := authorizer.Events("bob"); got != nil {
+		t.Errorf("want: nil, got: %v", got)
+	}
+	if _, ok := authorizer.State("bob"); ok {
+		t.Errorf("want Account %q not initialized", "bob")
+	}
+}
+
+func TestNewConcurrentAuthorizer(t *testing.T) {
+	for _, policy := range []LatePolicy{LateAccept, LateReject, LateReorder} {
+		t.Run(string(policy), func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.LatePolicy = policy
+			_, err := NewConcurrentAuthorizer(func() Timeline { return NewTimelineWithConfig(cfg) })
+			if want := policy == LateReorder; errors.Is(err, ErrInvalidConfig) != want {
+				t.Errorf("%s, want error: %v, got: %v", policy, want, err)
+			}
+		})
+	}
+}
+
+// newConcurrentAuthorizer returns a ConcurrentAuthorizer of DefaultConfig Timeline.
+func newConcurrentAuthorizer(t *testing.T) *ConcurrentAuthorizer {
+	t.Helper()
+	authorizer, err := NewConcurrentAuthorizer(NewTimeline)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return authorizer
+}
+
+// withAccountID returns a copy of the Event with the given Account ID.
+func withAccountID(ie Event, id string) Event {
+	c := TimelineEvent{Event: ie}.clone().Event
+	switch {
+	case c.Account != nil:
+		c.Account.ID = id
+	case c.Transaction != nil:
+		c.Transaction.AccountID = id
+	case c.Card != nil:
+		c.Card.AccountID = id
+	case c.Limit != nil:
+		c.Limit.AccountID = id
+	case c.Reversal != nil:
+		c.Reversal.AccountID = id
+	case c.Refund != nil:
+		c.Refund.AccountID = id
+	case c.Capture != nil:
+		c.Capture.AccountID = id
+	}
+
+	return c
+}
+
+// withoutAccountID removes the Account ID of all TimelineEvent.
+func withoutAccountID(events []TimelineEvent) []TimelineEvent {
+	for i := range events {
+		te := &events[i]
+		if te.Account != nil {
+			te.Account.ID = ""
+		}
+		for _, tr := range []*Transaction{te.Transaction, te.Expired} {
+			if tr != nil {
+				tr.AccountID = ""
+			}
+		}
+		if te.Card != nil {
+			te.Card.AccountID = ""
+		}
+		if te.Limit != nil {
+			te.Limit.AccountID = ""
+		}
+		if te.Reversal != nil {
+			te.Reversal.AccountID = ""
+		}
+		if te.Refund != nil {
+			te.Refund.AccountID = ""
+		}
+		if te.Capture != nil {
+			te.Capture.AccountID = ""
+		}
+	}
+
+	return events
+}
+
+func TestConcurrentAuthorizer_HoldExpiry(t *testing.T) {
+	authorizer := newConcurrentAuthorizer(t)
+	for _, ie := range []Event{
+		{Account: &Account{ID: "alice", ActiveCard: true, AvailableLimit: 100}},
+		{Transaction: &Transaction{ID: "hotel", AccountID: "alice", Merchant: "Calgary Flames", Amount: 70, Time: trTime}, Hold: true},
+		{Account: &Account{ID: "bob", ActiveCard: true, AvailableLimit: 100}},
+	} {
+		authorizer.Process(ie)
+	}
+	if got, _ := authorizer.State("alice"); got.HeldLimit != 70 {
+		t.Errorf("before expiry, want: %d, got: %d", 70, got.HeldLimit)
+	}
+
+	authorizer.Process(Event{Transaction: &Transaction{AccountID: "bob", Merchant: "Edmonton Oilers", Amount: 10, Time: aeTime}})
+	want := Account{ID: "alice", ActiveCard: true, AvailableLimit: 100}
+	if got, _ := authorizer.State("alice"); got != want {
+		t.Errorf("after expiry, want: %+v, got: %+v", want, got)
+	}
+	events := authorizer.Events("alice")
+	if got := events[len(events)-1].Expired; got == nil || got.ID != "hotel" {
+		t.Errorf("want: hotel expired, got: %+v", got)
+	}
+}
@@ -0,0 +1,161 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrInvalidConfig is returned when the Config breaches its contract.
+var ErrInvalidConfig = errors.New("invalid config")
+
+const (
+	// LateAccept processes late Event as any other one, which is only right when the input is in time order.
+	LateAccept = LatePolicy("accept")
+	// LateReject rejects the Event older than the latest one by more than Config.Lateness. The Timeline keeps the
+	// Transaction within the largest window plus Config.Lateness, so the windows of the accepted ones are complete.
+	// Accepted late Transaction are also rejected when they would push the window of a later valid one over its limit.
+	LateReject = LatePolicy("reject")
+	// LateReorder buffers the Event for Config.Lateness, so they are decided in time order, and rejects the ones
+	// that arrive after later Event were decided.
+	LateReorder = LatePolicy("reorder")
+)
+
+type (
+	// Config groups the thresholds of the built-in rules and the Timeline settings.
+	// It is loaded from a JSON file, e.g.:
+	//  {"window": "2m", "max-transactions": 3, "duplicate-window": "2m", "hold-expiry": "168h", "history-tail": 0,
+	//   "id-retention": "0s", "explain": false, "late-policy": "accept", "lateness": "0s"}
+	Config struct {
+		// Window is the interval taken into account by the high-frequency-small-interval rule.
+		Window duration `json:"window"`
+		// MaxTransactions is the number of valid Transaction allowed within Window.
+		MaxTransactions int `json:"max-transactions"`
+		// DuplicateWindow is the interval in which a second Transaction of the same Merchant is a double-Transaction.
+		DuplicateWindow duration `json:"duplicate-window"`
+		// HoldExpiry is how long an authorization holds the limit before it is released if it was not captured.
+		HoldExpiry duration `json:"hold-expiry"`
+		// HistoryTail is how many TimelineEvent the Timeline keeps for auditing. Older ones are compacted.
+		// When it is zero, all TimelineEvent are kept. It does not bound the decisions and approvals kept by ID.
+		HistoryTail int `json:"history-tail"`
+		// IDRetention is how long the decision and the approval of a Transaction with ID are kept, so it could be
+		// retried, reversed or refunded. When it is zero, they are kept forever. Otherwise, it must be at least
+		// HoldExpiry, so a pending authorization is never forgotten.
+		IDRetention duration `json:"id-retention"`
+		// Explain makes the Timeline add the Explanation of each violation to the TimelineEvent.
+		Explain bool `json:"explain"`
+		// LatePolicy is how the Timeline handles the Event older than the latest one.
+		LatePolicy LatePolicy `json:"late-policy"`
+		// Lateness is the bound of LateReject and the watermark delay of LateReorder.
+		Lateness duration `json:"lateness"`
+	}
+	// LatePolicy is a type created to abstract all constants late policies.
+	LatePolicy string
+
+	// duration is a wrapper type created to implement UnmarshalJSON and MarshalJSON in time.ParseDuration format.
+	duration time.Duration
+)
+
+// DefaultConfig returns the Config described in README.md.
+func DefaultConfig() Config {
+	return Config{
+		Window:          duration(2 * time.Minute),
+		MaxTransactions: 3,
+		DuplicateWindow: duration(2 * time.Minute),
+		HoldExpiry:      duration(7 * 24 * time.Hour),
+		LatePolicy:      LateAccept,
+	}
+}
+
+// LoadConfig reads a JSON Config file. Omitted properties keep the DefaultConfig values.
+// It returns an error when the file could not be read, has unknown properties or the Config is not valid.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg := DefaultConfig()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("%w: %s: %v", ErrInvalidConfig, path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// Validate returns an error wrapping ErrInvalidConfig when any threshold is not positive, history-tail or lateness is
+// negative, id-retention is neither zero nor at least hold-expiry, or late-policy is unknown.
+func (c Config) Validate() error {
+	switch {
+	case c.Window <= 0:
+		return fmt.Errorf("%w: window must be positive, got %s", ErrInvalidConfig, c.Window)
+	case c.MaxTransactions <= 0:
+		return fmt.Errorf("%w: max-transactions must be positive, got %d", ErrInvalidConfig, c.MaxTransactions)
+	case c.DuplicateWindow <= 0:
+		return fmt.Errorf("%w: duplicate-window must be positive, got %s", ErrInvalidConfig, c.DuplicateWindow)
+	case c.HoldExpiry <= 0:
+		return fmt.Errorf("%w: hold-expiry must be positive, got %s", ErrInvalidConfig, c.HoldExpiry)
+	case c.HistoryTail < 0:
+		return fmt.Errorf("%w: history-tail must not be negative, got %d", ErrInvalidConfig, c.HistoryTail)
+	case c.IDRetention != 0 && c.IDRetention < c.HoldExpiry:
+		return fmt.Errorf("%w: id-retention must be zero or at least hold-expiry %s, got %s", ErrInvalidConfig, c.HoldExpiry, c.IDRetention)
+	case c.LatePolicy != LateAccept && c.LatePolicy != LateReject && c.LatePolicy != LateReorder:
+		return fmt.Errorf("%w: late-policy must be %s, %s or %s, got %q", ErrInvalidConfig, LateAccept, LateReject, LateReorder, c.LatePolicy)
+	case c.Lateness < 0:
+		return fmt.Errorf("%w: lateness must not be negative, got %s", ErrInvalidConfig, c.Lateness)
+	}
+
+	return nil
+}
+
+// Rules returns the built-in Rules with the Config thresholds.
+func (c Config) Rules() Rules {
+	return NewRules(
+		Guard(AccountInitializedRule{}),
+		Guard(ActiveCardRule{}),
+		LimitRule{},
+		HighFrequencyRule{Max: c.MaxTransactions, Interval: time.Duration(c.Window)},
+		DoubleTransactionRule{Max: 1, Interval: time.Duration(c.DuplicateWindow)},
+	)
+}
+
+// String maps Config into the same JSON format it is loaded from.
+func (c Config) String() string {
+	str, _ := json.Marshal(c)
+
+	return string(str)
+}
+
+// UnmarshalJSON receives a []byte duration, e.g. "2m" or "90s", and parses it with time.ParseDuration.
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = duration(v)
+	return nil
+}
+
+// MarshalJSON maps duration into time.Duration string format.
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// String implements fmt.Stringer interface.
+func (d duration) String() string {
+	return time.Duration(d).String()
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want Config
+	}{
+		{"all properties", `{"window":"5m","max-transactions":5,"duplicate-window":"30s","hold-expiry":"24h","history-tail":100,` +
+			`"id-retention":"48h","explain":true,"late-policy":"reorder","lateness":"10s"}`,
+			Config{Window: duration(5 * time.Minute), MaxTransactions: 5, DuplicateWindow: duration(30 * time.Second),
+				HoldExpiry: duration(24 * time.Hour), HistoryTail: 100, IDRetention: duration(48 * time.Hour), Explain: true,
+				LatePolicy: LateReorder, Lateness: duration(10 * time.Second)}},
+		{"omitted properties", `{"max-transactions":10}`,
+			Config{Window: duration(2 * time.Minute), MaxTransactions: 10, DuplicateWindow: duration(2 * time.Minute),
+				HoldExpiry: duration(168 * time.Hour), LatePolicy: LateAccept}},
+		{"empty", `{}`, DefaultConfig()},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := LoadConfig(writeConfig(t, c.in))
+			if err != nil {
+				t.Fatalf("%s, unexpected error: %v", c.name, err)
+			}
+			if !reflect.DeepEqual(c.want, got) {
+				t.Errorf("%s, want: %v, got: %v", c.name, c.want, got)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+	}{
+		{"malformed JSON", `{"window":`},
+		{"unknown property", `{"window":"2m","max-duplicates":1}`},
+		{"invalid duration", `{"window":"two minutes"}`},
+		{"zero window", `{"window":"0s"}`},
+		{"negative duplicate window", `{"duplicate-window":"-1m"}`},
+		{"zero max transactions", `{"max-transactions":0}`},
+		{"zero hold expiry", `{"hold-expiry":"0s"}`},
+		{"negative history tail", `{"history-tail":-1}`},
+		{"negative id retention", `{"id-retention":"-1h"}`},
+		{"id retention shorter than hold expiry", `{"hold-expiry":"24h","id-retention":"23h"}`},
+		{"unknown late policy", `{"late-policy":"drop"}`},
+		{"negative lateness", `{"lateness":"-1s"}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := LoadConfig(writeConfig(t, c.in)); !errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("%s, want: %v, got: %v", c.name, ErrInvalidConfig, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_FileNotFound(t *testing.T) {
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("want: %v, got: %v", os.ErrNotExist, err)
+	}
+}
+
+func TestConfig_String(t *testing.T) {
+	want := `{"window":"2m0s","max-transactions":3,"duplicate-window":"2m0s","hold-expiry":"168h0m0s","history-tail":0,"id-retention":"0s",` +
+		`"explain":false,"late-policy":"accept","lateness":"0s"}`
+	if got := DefaultConfig().String(); got != want {
+		t.Errorf("want: %s, got: %s", want, got)
+	}
+}
+
+func TestConfig_Rules(t *testing.T) {
+	cfg := Config{Window: duration(time.Minute), MaxTransactions: 1, DuplicateWindow: duration(time.Minute), HoldExpiry: duration(time.Hour)}
+	timeline := NewTimelineWithRules(cfg.Rules())
+	timeline.Process(Event{Account: &Account{ActiveCard: true, AvailableLimit: 100}})
+	timeline.Process(Event{Transaction: &Transaction{Merchant: "Nashville Predators", Amount: 10, Time: hfTime}})
+	timeline.Process(Event{Transaction: &Transaction{Merchant: "Winnipeg Jets", Amount: 10, Time: hfTime2}})
+
+	want := []Violation{highFrequency}
+	if got := timeline.Last().Violations; !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errLineTooLong is the error of the Rejection of an input line longer than maxBodySize.
+var errLineTooLong = errors.New("line-too-long")
+
+type (
+	// Daemon exposes a ConcurrentAuthorizer through the line protocol of the standard input: each connection sends
+	// newline-delimited input events and receives one output line per input line, in order.
+	// All connections share the same Timeline of each Account.
+	Daemon struct {
+		// authorizer processes the Events of all connections.
+		authorizer *ConcurrentAuthorizer
+		// mu guards conns.
+		mu sync.Mutex
+		// conns has the open connections, so they could be closed on shutdown.
+		conns map[net.Conn]struct{}
+	}
+)
+
+// NewDaemon creates a new Daemon backed by the given ConcurrentAuthorizer.
+func NewDaemon(authorizer *ConcurrentAuthorizer) *Daemon {
+	return &Daemon{authorizer: authorizer, conns: make(map[net.Conn]struct{})}
+}
+
+// Serve accepts connections from the listener until ctx is done. Then it shuts down gracefully: it stops accepting
+// connections and waits up to drain for the clients to close theirs, before closing them.
+// It returns the first error accepting connections, unless ctx is done.
+func (d *Daemon) Serve(ctx context.Context, l net.Listener, drain time.Duration) error {
+	var wg sync.WaitGroup
+	accepted := make(chan error, 1)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				accepted <- err
+				return
+			}
+			d.track(conn, true)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer d.track(conn, false)
+				d.handle(conn)
+			}()
+		}
+	}()
+
+	var err error
+	select {
+	case err = <-accepted:
+	case <-ctx.Done():
+		l.Close()
+		<-accepted
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(drain):
+		d.closeAll()
+		<-done
+	}
+
+	return err
+}
+
+// handle answers the input lines of the connection until the client closes it. Lines longer than maxBodySize are
+// answered with a Rejection, without being buffered.
+// Replies are flushed whenever there is no more input buffered, so pipelined input is answered in batches.
+func (d *Daemon) handle(conn net.Conn) {
+	defer conn.Close()
+	r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+	for line := 1; ; line++ {
+		input, err := readLine(r)
+		if err == errLineTooLong {
+			w.WriteString(Rejection{Line: line, Err: err}.String() + "\n")
+			err = nil
+		} else if err != nil && input == "" {
+			return
+		} else if ie, perr := Parse(strings.TrimSuffix(strings.TrimSuffix(input, "\n"), "\r")); perr != nil {
+			w.WriteString(Rejection{Line: line, Err: perr}.String() + "\n")
+		} else {
+			w.WriteString(d.authorizer.Process(ie).String() + "\n")
+		}
+		if r.Buffered() == 0 || err != nil {
+			if w.Flush() != nil || err == io.EOF {
+				return
+			}
+		}
+	}
+}
+
+// readLine reads the next line of r, including its line break. When the line, without its line break, is longer than
+// maxBodySize, it discards the line and returns errLineTooLong.
+func readLine(r *bufio.Reader) (string, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if len(line)+len(bytes.TrimSuffix(chunk, []byte("\n"))) > maxBodySize {
+			for err == bufio.ErrBufferFull {
+				_, err = r.ReadSlice('\n')
+			}
+			if err == nil || err == io.EOF {
+				err = errLineTooLong
+			}
+			return "", err
+		}
+		line = append(line, chunk...)
+		if err != bufio.ErrBufferFull {
+			return string(line), err
+		}
+	}
+}
+
+// track adds the connection into conns when open is true. Otherwise, it removes the connection.
+func (d *Daemon) track(conn net.Conn, open bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if open {
+		d.conns[conn] = struct{}{}
+	} else {
+		delete(d.conns, conn)
+	}
+}
+
+// closeAll closes all open connections.
+func (d *Daemon) closeAll() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for conn := range d.conns {
+		conn.Close()
+	}
+}
@@ -0,0 +1,152 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDaemon_Serve(t *testing.T) {
+	path, stop := serveDaemon(t, time.Second)
+	defer stop()
+	alice, bob := dialDaemon(t, path), dialDaemon(t, path)
+	defer alice.Close()
+	defer bob.Close()
+
+	cases := []struct {
+		name string
+		conn *daemonConn
+		in   string
+		want string
+	}{
+		{"account", alice, `{"account":{"account-id":"alice","active-card":true,"available-limit":100}}`,
+			`{"Account":{"account-id":"alice","active-card":true,"available-limit":100},"violations":[]}`},
+		{"transaction of another connection", bob, `{"transaction":{"account-id":"alice","merchant":"Detroit Red Wings","amount":20,"time":"2019-02-13T11:00:00.000Z"}}`,
+			`{"Account":{"account-id":"alice","active-card":true,"available-limit":80},"violations":[]}`},
+		{"malformed line", bob, `{"transaction":`, `{"line":2,"error":"malformed-json","detail":"unexpected end of JSON input"}`},
+		{"line too long", bob, strings.Repeat(" ", maxBodySize+1), `{"line":3,"error":"line-too-long"}`},
+		{"line after a line too long", bob, `{"transaction":`, `{"line":4,"error":"malformed-json","detail":"unexpected end of JSON input"}`},
+		{"double transaction", alice, `{"transaction":{"account-id":"alice","merchant":"Detroit Red Wings","amount":20,"time":"2019-02-13T11:01:00.000Z"}}`,
+			`{"Account":{"account-id":"alice","active-card":true,"available-limit":80},"violations":["double-Transaction"]}`},
+		{"CRLF line", alice, `{"account":{"account-id":"alice","active-card":true,"available-limit":10}}` + "\r",
+			`{"Account":{"account-id":"alice","active-card":true,"available-limit":80},"violations":["Account-already-initialized"]}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.conn.roundTrip(t, c.in); got != c.want {
+				t.Errorf("%s, want: %s, got: %s", c.name, c.want, got)
+			}
+		})
+	}
+}
+
+func TestDaemon_Serve_Pipelined(t *testing.T) {
+	path, stop := serveDaemon(t, time.Second)
+	defer stop()
+	conn := dialDaemon(t, path)
+	defer conn.Close()
+
+	in := lineStream(4, 1000)
+	go io.WriteString(conn, in)
+
+	want := strings.Split(strings.TrimSuffix(sequential(in), "\n"), "\n")
+	for i := range want {
+		got, err := conn.r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("line %d, want: %s, got: %v", i+1, want[i], err)
+		}
+		if got = strings.TrimSuffix(got, "\n"); got != want[i] {
+			t.Fatalf("line %d, want: %s, got: %s", i+1, want[i], got)
+		}
+	}
+}
+
+func TestDaemon_Serve_LastLineWithoutBreak(t *testing.T) {
+	path, stop := serveDaemon(t, time.Second)
+	defer stop()
+	conn := dialDaemon(t, path)
+	defer conn.Close()
+
+	io.WriteString(conn, `{"account":{"active-card":true,"available-limit":100}}`)
+	conn.Conn.(*net.UnixConn).CloseWrite()
+
+	out, err := io.ReadAll(conn.r)
+	if want := `{"Account":{"active-card":true,"available-limit":100},"violations":[]}` + "\n"; err != nil || string(out) != want {
+		t.Errorf("want: %s, got: %s, %v", want, out, err)
+	}
+}
+
+// TestDaemon_Serve_Shutdown checks that idle connections are closed once drain is over.
+func TestDaemon_Serve_Shutdown(t *testing.T) {
+	path, stop := serveDaemon(t, 50*time.Millisecond)
+	conn := dialDaemon(t, path)
+	defer conn.Close()
+	conn.roundTrip(t, `{"account":{"active-card":true,"available-limit":100}}`)
+
+	stop()
+	if _, err := conn.r.ReadString('\n'); err != io.EOF {
+		t.Errorf("want: %v, got: %v", io.EOF, err)
+	}
+	if _, err := net.Dial("unix", path); err == nil {
+		t.Errorf("want connection refused after shutdown")
+	}
+}
+
+// daemonConn is a client connection to a Daemon.
+type daemonConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// roundTrip sends the input line and returns the output line.
+func (c *daemonConn) roundTrip(t *testing.T, in string) string {
+	t.Helper()
+	if _, err := io.WriteString(c, in+"\n"); err != nil {
+		t.Fatal(err)
+	}
+	out, err := c.r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.TrimSuffix(out, "\n")
+}
+
+// serveDaemon serves a Daemon on a Unix socket and returns its path and a function that stops it.
+func serveDaemon(t *testing.T, drain time.Duration) (string, func()) {
+	path := filepath.Join(t.TempDir(), "authorizer.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	daemon := NewDaemon(newConcurrentAuthorizer(t))
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- daemon.Serve(ctx, l, drain)
+	}()
+
+	return path, func() {
+		cancel()
+		if err := <-served; err != nil {
+			t.Errorf("want: nil, got: %v", err)
+		}
+	}
+}
+
+// dialDaemon connects to the Daemon of the given Unix socket path.
+func dialDaemon(t *testing.T, path string) *daemonConn {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &daemonConn{Conn: conn, r: bufio.NewReader(conn)}
+}
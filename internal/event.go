@@ -2,33 +2,172 @@ package internal
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 )
 
+var (
+	// ErrMalformedJSON is returned when the input is not a valid JSON object.
+	ErrMalformedJSON = errors.New("malformed-json")
+	// ErrUnknownEventType is returned when the input has an event type that is not in eventTypes.
+	ErrUnknownEventType = errors.New("unknown-event-type")
+	// ErrAmbiguousEvent is returned when the input has more than one event type, e.g. both Account and Transaction.
+	ErrAmbiguousEvent = errors.New("ambiguous-event")
+	// ErrEmptyEvent is returned when the input has no event type, e.g. neither Account nor Transaction.
+	ErrEmptyEvent = errors.New("empty-event")
+	// ErrInvalidTime is returned when the Transaction time is not a RFC-3339 datetime.
+	ErrInvalidTime = errors.New("invalid-time")
+	// ErrNegativeAmount is returned when either the Transaction amount or the Account limit is negative.
+	ErrNegativeAmount = errors.New("negative-amount")
+	// ErrMissingID is returned when an authorization does not have ID, so it could never be captured.
+	ErrMissingID = errors.New("missing-id")
+)
+
+// eventTypes maps each input event type into the function that decodes it into the Event.
+// Event types are case insensitive.
+var eventTypes = map[string]func(data []byte, ie *Event) error{
+	"account": func(data []byte, ie *Event) error {
+		return json.Unmarshal(data, &ie.Account)
+	},
+	"transaction": func(data []byte, ie *Event) error {
+		return json.Unmarshal(data, &ie.Transaction)
+	},
+	"card-activated": decodeCard(true),
+	"card-blocked":   decodeCard(false),
+	"limit-increase": decodeLimit(LimitIncrease),
+	"limit-decrease": decodeLimit(LimitDecrease),
+	"set-limit":      decodeLimit(LimitSet),
+	"reversal": func(data []byte, ie *Event) error {
+		return json.Unmarshal(data, &ie.Reversal)
+	},
+	"refund": func(data []byte, ie *Event) error {
+		return json.Unmarshal(data, &ie.Refund)
+	},
+	"authorization": func(data []byte, ie *Event) error {
+		if err := json.Unmarshal(data, &ie.Transaction); err != nil || ie.Transaction == nil {
+			return err
+		}
+		ie.Hold = true
+
+		return nil
+	},
+	"capture": func(data []byte, ie *Event) error {
+		return json.Unmarshal(data, &ie.Capture)
+	},
+}
+
+const (
+	// LimitIncrease adds the LimitChange Amount to the available limit.
+	LimitIncrease = LimitOperation("limit-increase")
+	// LimitDecrease subtracts the LimitChange Amount from the available limit.
+	LimitDecrease = LimitOperation("limit-decrease")
+	// LimitSet replaces the available limit by the LimitChange Amount.
+	LimitSet = LimitOperation("set-limit")
+)
+
 type (
 	// Account groups information about an Account.
-	Account   struct {
+	Account struct {
+		// ID identifies the Account. It is empty when the input has a single Account.
+		ID string `json:"account-id,omitempty"`
 		// ActiveCard when is true indicates that is possible to transact with this Account.
-		ActiveCard     bool `json:"active-card"`
+		ActiveCard bool `json:"active-card"`
 		// AvailableLimit indicates how much limit this account can transact.
-		AvailableLimit int  `json:"available-limit"`
+		AvailableLimit int `json:"available-limit"`
+		// HeldLimit indicates how much limit is held by authorizations that were not captured yet.
+		// It is not part of the input, it is not available to transact.
+		HeldLimit int `json:"-"`
 	}
 	// Transaction groups information about an Transaction.
 	Transaction struct {
+		// ID identifies the Transaction, so it could be reversed or refunded later and retried safely.
+		// It is optional.
+		ID string `json:"id,omitempty"`
+		// AccountID identifies the Account of the Transaction. It is empty when the input has a single Account.
+		AccountID string `json:"account-id,omitempty"`
 		// Merchant is the name of the Merchant that sent Transaction through acquirer.
-		Merchant string   `json:"merchant"`
+		Merchant string `json:"merchant"`
 		// Amount is the value of the Transaction without any cents.
-		Amount   int      `json:"amount"`
+		Amount int `json:"amount"`
 		// Time is the datetime of the Transaction in UTC.
-		Time     datetime `json:"time"`
+		Time datetime `json:"time"`
+	}
+	// CardStatus groups information about a card activation or blocking.
+	CardStatus struct {
+		// AccountID identifies the Account of the card. It is empty when the input has a single Account.
+		AccountID string `json:"account-id,omitempty"`
+		// Active is true for card-activated events and false for card-blocked events.
+		// It is not part of the input, it is set according the event type.
+		Active bool `json:"-"`
+	}
+	// LimitChange groups information about a credit limit adjustment.
+	LimitChange struct {
+		// AccountID identifies the Account of the limit. It is empty when the input has a single Account.
+		AccountID string `json:"account-id,omitempty"`
+		// Amount is the value increased, decreased or set according Operation, without any cents.
+		Amount int `json:"amount"`
+		// Operation is how Amount changes the available limit.
+		// It is not part of the input, it is set according the event type.
+		Operation LimitOperation `json:"-"`
+	}
+	// LimitOperation is a type created to abstract all constants limit operations.
+	LimitOperation string
+	// Reversal groups information about the reversal of a previous Transaction.
+	// It restores the whole amount of the Transaction that was not refunded yet.
+	Reversal struct {
+		// AccountID identifies the Account of the Transaction. It is empty when the input has a single Account.
+		AccountID string `json:"account-id,omitempty"`
+		// TransactionID is the ID of the reversed Transaction.
+		TransactionID string `json:"transaction-id"`
+		// Time is the datetime of the Reversal in UTC.
+		Time datetime `json:"time"`
+	}
+	// Refund groups information about a partial return of a previous Transaction.
+	Refund struct {
+		// AccountID identifies the Account of the Transaction. It is empty when the input has a single Account.
+		AccountID string `json:"account-id,omitempty"`
+		// TransactionID is the ID of the refunded Transaction.
+		TransactionID string `json:"transaction-id"`
+		// Amount is the refunded value without any cents.
+		Amount int `json:"amount"`
+		// Time is the datetime of the Refund in UTC.
+		Time datetime `json:"time"`
+	}
+	// Capture groups information about the settlement of a previous authorization.
+	Capture struct {
+		// AccountID identifies the Account of the authorization. It is empty when the input has a single Account.
+		AccountID string `json:"account-id,omitempty"`
+		// AuthorizationID is the ID of the captured authorization.
+		AuthorizationID string `json:"authorization-id"`
+		// Amount is the settled value without any cents. It must not exceed the authorization amount.
+		Amount int `json:"amount"`
+		// Time is the datetime of the Capture in UTC.
+		Time datetime `json:"time"`
 	}
 	// Event represents an input Event.
+	// Only one of its properties is present.
 	Event struct {
 		// Account related to the Event.
-		*Account     `json:"Account"`
+		*Account `json:"Account"`
 		// Transaction related to the Event.
 		*Transaction `json:"Transaction"`
+		// Card is present when the Event activates or blocks the card.
+		Card *CardStatus `json:"-"`
+		// Limit is present when the Event adjusts the available limit.
+		Limit *LimitChange `json:"-"`
+		// Reversal is present when the Event reverses a previous Transaction.
+		Reversal *Reversal `json:"-"`
+		// Refund is present when the Event refunds a previous Transaction.
+		Refund *Refund `json:"-"`
+		// Hold is true when the Transaction is an authorization that only holds its amount until it is captured.
+		Hold bool `json:"-"`
+		// Capture is present when the Event settles a previous authorization.
+		Capture *Capture `json:"-"`
+		// Expired is present when the Event releases an authorization that was not captured in time.
+		// It is never part of the input.
+		Expired *Transaction `json:"-"`
 	}
 	// TimelineEvent represents each event of the Timeline.
 	// It could be a valid Event (Violations empty) or a invalid Event.
@@ -38,22 +177,49 @@ type (
 		Event
 		// Violations has all Violations of this TimelineEvents.
 		// It is never nil. When this is empty, the TimelineEvent is valid.
-		Violations []violation
+		Violations []Violation
+		// Replay is true when the TimelineEvent repeats the decision of a previous Transaction with the same ID.
+		// It never changes the Timeline state.
+		Replay bool
+		// Explanations has the Explanation of each violation, in the same order of Violations.
+		// It is nil unless the Timeline explains its violations, see Config.Explain.
+		Explanations []Explanation
+	}
+	// Violation is a type created to abstract all constants violations.
+	Violation string
+
+	// ParseError is returned by Parse when the input breaches the contract.
+	// Kind is always one of the Err* variables of this package and could be checked with errors.Is.
+	ParseError struct {
+		// Kind is the reason why the input was rejected.
+		Kind error
+		// Detail is a human readable description of the failure.
+		Detail string
+	}
+	// Rejection represents an input line that could not be parsed.
+	Rejection struct {
+		// Line is the 1-based line number of the input. It is zero, and omitted in JSON, when the input has no lines.
+		Line int
+		// Err is the error returned by Parse.
+		Err error
 	}
 
 	// datetime is a wrapper type created to implement UnmarshalJSON.
-	datetime      time.Time
-	// violation is a type created to abstract all constants violations.
-	violation     string
+	datetime time.Time
 	// outputAccount is a structured created to represent a TimelineEvent.
 	// This new structure is need because properties must be pointers to be compliance with functional requirements.
 	outputAccount struct {
+		// ID identifies the Account. When it is empty, must be omitted in JSON.
+		ID string `json:"account-id,omitempty"`
 		// ActiveCard when is true indicates that is possible to transact with this Account.
 		// When it is nil, must be omitted in JSON.
-		ActiveCard     *bool `json:"active-card,omitempty"`
+		ActiveCard *bool `json:"active-card,omitempty"`
 		// AvailableLimit indicates how much limit this account can transact.
 		// When it is nil, must be omitted in JSON.
-		AvailableLimit *int  `json:"available-limit,omitempty"`
+		AvailableLimit *int `json:"available-limit,omitempty"`
+		// HeldLimit indicates how much limit is held by authorizations.
+		// When it is zero, must be omitted in JSON.
+		HeldLimit int `json:"held-limit,omitempty"`
 	}
 	// output is the output.
 	// This new structure is need to avoid print Transaction in standard output.
@@ -61,46 +227,209 @@ type (
 		outputAccount `json:"Account"`
 		// Violations has all Violations of this TimelineEvents.
 		// It is never nil.
-		Violations    []violation `json:"violations"`
+		Violations []Violation `json:"violations"`
+		// Explanations has the Explanation of each violation. When it is nil, must be omitted in JSON.
+		Explanations []Explanation `json:"explanations,omitempty"`
+	}
+	// rejectionOutput is the output of a Rejection.
+	rejectionOutput struct {
+		Line   int    `json:"line,omitempty"`
+		Error  string `json:"error"`
+		Detail string `json:"detail,omitempty"`
 	}
 )
 
 // Parse receives a JSON input in string format and parses it into an Event.
-// For simplicity, it does not handle invalid input or breach of contract.
-func Parse(input string) Event {
+// It returns a *ParseError when the input is not a valid Event. In this case the returned Event is empty.
+func Parse(input string) (Event, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(input), &raw); err != nil || raw == nil {
+		return Event{}, newParseError(ErrMalformedJSON, err)
+	}
+
+	var ie Event
+	for k, data := range raw {
+		if err := decodeType(k, data, &ie); err != nil {
+			return Event{}, err
+		}
+	}
+
+	// Each key counts as an event type even when it is null, since a null key of a type that shares the member
+	// of another one, e.g. "Account" and "account", would reset it according the order of the keys.
+	switch {
+	case len(raw) > 1:
+		return Event{}, &ParseError{Kind: ErrAmbiguousEvent, Detail: "event has more than one event type"}
+	case ie == (Event{}):
+		return Event{}, &ParseError{Kind: ErrEmptyEvent, Detail: "event has no event type"}
+	}
+
+	if err := ie.validate(); err != nil {
+		return Event{}, err
+	}
+
+	return ie, nil
+}
+
+// ParseType receives the JSON input of a single event type, e.g. the Account of an "account" event, and parses it
+// into an Event. It returns a *ParseError when the input is not a valid Event. In this case the returned Event is empty.
+func ParseType(eventType string, input []byte) (Event, error) {
 	var ie Event
-	json.Unmarshal([]byte(input), &ie)
+	if err := decodeType(eventType, input, &ie); err != nil {
+		return Event{}, err
+	}
+	if ie == (Event{}) {
+		return Event{}, &ParseError{Kind: ErrEmptyEvent, Detail: "event has no event type"}
+	}
+
+	if err := ie.validate(); err != nil {
+		return Event{}, err
+	}
+
+	return ie, nil
+}
+
+// decodeType decodes data into the Event according the event type.
+// It returns a *ParseError when the event type is unknown or data is not valid.
+func decodeType(eventType string, data []byte, ie *Event) error {
+	decode, ok := eventTypes[strings.ToLower(eventType)]
+	if !ok {
+		return &ParseError{Kind: ErrUnknownEventType, Detail: fmt.Sprintf("unknown event type %q", eventType)}
+	}
+	if err := decode(data, ie); err != nil {
+		var pe *ParseError
+		if errors.As(err, &pe) {
+			return pe
+		}
+		return newParseError(ErrMalformedJSON, err)
+	}
 
-	return ie
+	return nil
+}
+
+// validate checks the contract of a decoded Event.
+func (e Event) validate() error {
+	switch {
+	case e.Account != nil && e.AvailableLimit < 0:
+		return &ParseError{Kind: ErrNegativeAmount, Detail: "available-limit must not be negative"}
+	case e.Transaction != nil && e.Amount < 0:
+		return &ParseError{Kind: ErrNegativeAmount, Detail: "amount must not be negative"}
+	case e.Limit != nil && e.Limit.Amount < 0:
+		return &ParseError{Kind: ErrNegativeAmount, Detail: "amount must not be negative"}
+	case e.Refund != nil && e.Refund.Amount < 0:
+		return &ParseError{Kind: ErrNegativeAmount, Detail: "amount must not be negative"}
+	case e.Capture != nil && e.Capture.Amount < 0:
+		return &ParseError{Kind: ErrNegativeAmount, Detail: "amount must not be negative"}
+	case e.Hold && e.Transaction.ID == "":
+		return &ParseError{Kind: ErrMissingID, Detail: "authorization must have id"}
+	}
+
+	return nil
+}
+
+// decodeCard returns a function that decodes a CardStatus with the given Active value.
+func decodeCard(active bool) func(data []byte, ie *Event) error {
+	return func(data []byte, ie *Event) error {
+		if err := json.Unmarshal(data, &ie.Card); err != nil || ie.Card == nil {
+			return err
+		}
+		ie.Card.Active = active
+
+		return nil
+	}
+}
+
+// decodeLimit returns a function that decodes a LimitChange with the given Operation.
+func decodeLimit(op LimitOperation) func(data []byte, ie *Event) error {
+	return func(data []byte, ie *Event) error {
+		if err := json.Unmarshal(data, &ie.Limit); err != nil || ie.Limit == nil {
+			return err
+		}
+		ie.Limit.Operation = op
+
+		return nil
+	}
 }
 
 // UnmarshalJSON receives a []byte datetime and parses it into RFC-3339 datetime standard.
-// For simplicity, it does not handle invalid input or breach of contract.
+// It returns a *ParseError of kind ErrInvalidTime when data is not a RFC-3339 string.
 func (it *datetime) UnmarshalJSON(data []byte) error {
-	s := strings.Trim(string(data), `"`)
-	t, _ := time.Parse(time.RFC3339, s)
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return newParseError(ErrInvalidTime, err)
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return newParseError(ErrInvalidTime, err)
+	}
 
 	*it = datetime(t)
 	return nil
 }
 
+// MarshalJSON formats the datetime in RFC-3339 standard, so it could be read back by UnmarshalJSON.
+func (it datetime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Time(it).Format(time.RFC3339Nano))
+}
+
+// newParseError creates a *ParseError of the given kind. The cause, when present, is used as Detail.
+func newParseError(kind error, cause error) *ParseError {
+	pe := &ParseError{Kind: kind}
+	if cause != nil {
+		pe.Detail = cause.Error()
+	}
+
+	return pe
+}
+
+// Error implements error interface.
+func (pe *ParseError) Error() string {
+	if pe.Detail == "" {
+		return pe.Kind.Error()
+	}
+
+	return fmt.Sprintf("%s: %s", pe.Kind, pe.Detail)
+}
+
+// Unwrap returns the Kind of the ParseError, so it could be checked with errors.Is.
+func (pe *ParseError) Unwrap() error {
+	return pe.Kind
+}
+
+// String maps Rejection into a JSON line with the line number, the error kind and its detail.
+func (r Rejection) String() string {
+	op := rejectionOutput{Line: r.Line, Error: r.Err.Error()}
+
+	var pe *ParseError
+	if errors.As(r.Err, &pe) {
+		op.Error = pe.Kind.Error()
+		op.Detail = pe.Detail
+	}
+
+	str, _ := json.Marshal(op)
+
+	return string(str)
+}
+
 // String maps TimelineEvent into output that is compliance with functional requirements.
 func (te TimelineEvent) String() string {
 	op := output{
 		outputAccount: outputAccount{
+			ID:             te.accountID(),
 			ActiveCard:     nil,
 			AvailableLimit: nil,
 		},
-		Violations: make([]violation, 0),
+		Violations: make([]Violation, 0),
 	}
 
 	if te.Account != nil {
 		op.ActiveCard = &te.ActiveCard
 		op.AvailableLimit = &te.AvailableLimit
+		op.HeldLimit = te.HeldLimit
 	}
 
 	if te.hasViolation() {
 		op.Violations = te.Violations
+		op.Explanations = te.Explanations
 	}
 
 	str, _ := json.Marshal(op)
@@ -113,6 +442,100 @@ func (e Event) isTransaction() bool {
 	return e.Transaction != nil
 }
 
+// accountID returns the ID of the Account related to the Event.
+// It is empty when the Event does not have an Account ID.
+func (e Event) accountID() string {
+	switch {
+	case e.Account != nil:
+		return e.Account.ID
+	case e.Transaction != nil:
+		return e.Transaction.AccountID
+	case e.Card != nil:
+		return e.Card.AccountID
+	case e.Limit != nil:
+		return e.Limit.AccountID
+	case e.Reversal != nil:
+		return e.Reversal.AccountID
+	case e.Refund != nil:
+		return e.Refund.AccountID
+	case e.Capture != nil:
+		return e.Capture.AccountID
+	}
+
+	return ""
+}
+
+// time returns the datetime of the Event. It is zero when the Event does not have datetime.
+func (e Event) time() time.Time {
+	switch {
+	case e.Transaction != nil:
+		return time.Time(e.Transaction.Time)
+	case e.Reversal != nil:
+		return time.Time(e.Reversal.Time)
+	case e.Refund != nil:
+		return time.Time(e.Refund.Time)
+	case e.Capture != nil:
+		return time.Time(e.Capture.Time)
+	}
+
+	return time.Time{}
+}
+
+// clone returns a deep copy of the TimelineEvent, so it does not share memory with the Timeline.
+func (te TimelineEvent) clone() TimelineEvent {
+	c := te
+	c.Violations = append(make([]Violation, 0, len(te.Violations)), te.Violations...)
+	if te.Explanations != nil {
+		c.Explanations = make([]Explanation, len(te.Explanations))
+		for i, e := range te.Explanations {
+			c.Explanations[i] = e.clone()
+		}
+	}
+	if te.Account != nil {
+		acc := *te.Account
+		c.Account = &acc
+	}
+	if te.Transaction != nil {
+		tr := *te.Transaction
+		c.Transaction = &tr
+	}
+	if te.Card != nil {
+		cs := *te.Card
+		c.Card = &cs
+	}
+	if te.Limit != nil {
+		lc := *te.Limit
+		c.Limit = &lc
+	}
+	if te.Reversal != nil {
+		r := *te.Reversal
+		c.Reversal = &r
+	}
+	if te.Refund != nil {
+		r := *te.Refund
+		c.Refund = &r
+	}
+	if te.Capture != nil {
+		cp := *te.Capture
+		c.Capture = &cp
+	}
+	if te.Expired != nil {
+		tr := *te.Expired
+		c.Expired = &tr
+	}
+
+	return c
+}
+
+// equal is true when both Transaction have the same content.
+func (tr Transaction) equal(other Transaction) bool {
+	return tr.ID == other.ID &&
+		tr.AccountID == other.AccountID &&
+		tr.Merchant == other.Merchant &&
+		tr.Amount == other.Amount &&
+		time.Time(tr.Time).Equal(time.Time(other.Time))
+}
+
 // hasViolation is true when TimelineEvent has any violation.
 func (te TimelineEvent) hasViolation() bool {
 	return len(te.Violations) > 0
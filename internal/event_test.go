@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 	"time"
@@ -14,17 +15,146 @@ func TestParse(t *testing.T) {
 	}{
 		{"Account", accJSON, accEvent},
 		{"Transaction", trJSON, trEvent},
+		{"Account with ID", `{"account":{"account-id":"alice","active-card":true,"available-limit":666}}`,
+			Event{Account: &Account{ID: "alice", ActiveCard: true, AvailableLimit: 666}}},
+		{"Transaction with Account ID", `{"transaction":{"account-id":"alice","merchant":"Montreal Canadiens","amount":666,"time":"2019-02-13T11:00:00.000Z"}}`,
+			Event{Transaction: &Transaction{AccountID: "alice", Merchant: "Montreal Canadiens", Amount: 666, Time: trEvent.Time}}},
+		{"card-activated", `{"card-activated":{"account-id":"alice"}}`, Event{Card: &CardStatus{AccountID: "alice", Active: true}}},
+		{"card-blocked", `{"Card-Blocked":{}}`, Event{Card: &CardStatus{Active: false}}},
+		{"limit-increase", `{"limit-increase":{"account-id":"alice","amount":10}}`,
+			Event{Limit: &LimitChange{AccountID: "alice", Amount: 10, Operation: LimitIncrease}}},
+		{"limit-decrease", `{"limit-decrease":{"amount":10}}`, Event{Limit: &LimitChange{Amount: 10, Operation: LimitDecrease}}},
+		{"set-limit", `{"set-limit":{"amount":0}}`, Event{Limit: &LimitChange{Amount: 0, Operation: LimitSet}}},
+		{"Transaction with ID", `{"transaction":{"id":"t1","merchant":"Montreal Canadiens","amount":666,"time":"2019-02-13T11:00:00.000Z"}}`,
+			Event{Transaction: &Transaction{ID: "t1", Merchant: "Montreal Canadiens", Amount: 666, Time: trEvent.Time}}},
+		{"reversal", `{"reversal":{"transaction-id":"t1","time":"2019-02-13T11:00:00.000Z"}}`,
+			Event{Reversal: &Reversal{TransactionID: "t1", Time: trEvent.Time}}},
+		{"refund", `{"refund":{"account-id":"alice","transaction-id":"t1","amount":10,"time":"2019-02-13T11:00:00.000Z"}}`,
+			Event{Refund: &Refund{AccountID: "alice", TransactionID: "t1", Amount: 10, Time: trEvent.Time}}},
+		{"authorization", `{"authorization":{"id":"a1","merchant":"Montreal Canadiens","amount":666,"time":"2019-02-13T11:00:00.000Z"}}`,
+			Event{Transaction: &Transaction{ID: "a1", Merchant: "Montreal Canadiens", Amount: 666, Time: trEvent.Time}, Hold: true}},
+		{"capture", `{"capture":{"authorization-id":"a1","amount":600,"time":"2019-02-13T11:00:00.000Z"}}`,
+			Event{Capture: &Capture{AuthorizationID: "a1", Amount: 600, Time: trEvent.Time}}},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			if got := Parse(c.in); !reflect.DeepEqual(c.want, got) {
+			got, err := Parse(c.in)
+			if err != nil {
+				t.Fatalf("%s, unexpected error: %v", c.name, err)
+			}
+			if !reflect.DeepEqual(c.want, got) {
+				t.Errorf("%s, want: %v, got: %v", c.name, c.want, got)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want error
+	}{
+		{"malformed JSON", `{"Account":`, ErrMalformedJSON},
+		{"not an object", `[1, 2]`, ErrMalformedJSON},
+		{"null", `null`, ErrMalformedJSON},
+		{"wrong field type", `{"Account":{"active-card":"yes","available-limit":666}}`, ErrMalformedJSON},
+		{"unknown event type", `{"Transfer":{"amount":666}}`, ErrUnknownEventType},
+		{"both Account and Transaction", `{"Account":{"active-card":true,"available-limit":666},` +
+			`"Transaction":{"merchant":"Montreal Canadiens","amount":666,"time":"2019-02-13T11:00:00.000Z"}}`, ErrAmbiguousEvent},
+		{"neither Account nor Transaction", `{}`, ErrEmptyEvent},
+		{"null Account", `{"Account":null}`, ErrEmptyEvent},
+		{"both card-activated and card-blocked", `{"card-activated":{},"card-blocked":{}}`, ErrAmbiguousEvent},
+		{"null card-blocked", `{"card-blocked":null}`, ErrEmptyEvent},
+		{"bad timestamp", `{"Transaction":{"merchant":"Montreal Canadiens","amount":666,"time":"13/02/2019"}}`, ErrInvalidTime},
+		{"non string timestamp", `{"Transaction":{"merchant":"Montreal Canadiens","amount":666,"time":1550055600}}`, ErrInvalidTime},
+		{"negative amount", `{"Transaction":{"merchant":"Montreal Canadiens","amount":-1,"time":"2019-02-13T11:00:00.000Z"}}`, ErrNegativeAmount},
+		{"negative limit", `{"Account":{"active-card":true,"available-limit":-1}}`, ErrNegativeAmount},
+		{"negative limit change", `{"set-limit":{"amount":-1}}`, ErrNegativeAmount},
+		{"negative refund", `{"refund":{"transaction-id":"t1","amount":-1}}`, ErrNegativeAmount},
+		{"refund with bad timestamp", `{"refund":{"transaction-id":"t1","amount":1,"time":"now"}}`, ErrInvalidTime},
+		{"authorization without ID", `{"authorization":{"merchant":"Montreal Canadiens","amount":666,"time":"2019-02-13T11:00:00.000Z"}}`, ErrMissingID},
+		{"both authorization and Transaction", `{"authorization":{"id":"a1"},"transaction":{"id":"a1"}}`, ErrAmbiguousEvent},
+		{"negative capture", `{"capture":{"authorization-id":"a1","amount":-1}}`, ErrNegativeAmount},
+		{"Account and null account", `{"account":{"active-card":true,"available-limit":100},"Account":null}`, ErrAmbiguousEvent},
+		{"Transaction and null authorization", `{"transaction":{"merchant":"Montreal Canadiens","amount":666,` +
+			`"time":"2019-02-13T11:00:00.000Z"},"authorization":null}`, ErrAmbiguousEvent},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := Parse(c.in)
+			if !errors.Is(err, c.want) {
+				t.Errorf("%s, want: %v, got: %v", c.name, c.want, err)
+			}
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Errorf("%s, want a *ParseError, got: %T", c.name, err)
+			}
+			if !reflect.DeepEqual(Event{}, got) {
+				t.Errorf("%s, want empty Event, got: %v", c.name, got)
+			}
+		})
+	}
+}
+
+func TestParseType(t *testing.T) {
+	cases := []struct {
+		name      string
+		eventType string
+		in        string
+		want      Event
+		err       error
+	}{
+		{"Account", "account", `{"account-id":"alice","active-card":true,"available-limit":666}`,
+			Event{Account: &Account{ID: "alice", ActiveCard: true, AvailableLimit: 666}}, nil},
+		{"Transaction", "Transaction", `{"merchant":"Montreal Canadiens","amount":666,"time":"2019-02-13T11:00:00.000Z"}`,
+			Event{Transaction: &Transaction{Merchant: "Montreal Canadiens", Amount: 666, Time: trEvent.Time}}, nil},
+		{"card-blocked", "card-blocked", `{}`, Event{Card: &CardStatus{Active: false}}, nil},
+		{"malformed JSON", "account", `{"active-card":`, Event{}, ErrMalformedJSON},
+		{"not an object", "account", `[1, 2]`, Event{}, ErrMalformedJSON},
+		{"null", "transaction", `null`, Event{}, ErrEmptyEvent},
+		{"unknown event type", "transfer", `{"amount":666}`, Event{}, ErrUnknownEventType},
+		{"negative limit", "account", `{"active-card":true,"available-limit":-1}`, Event{}, ErrNegativeAmount},
+		{"authorization without ID", "authorization", `{"merchant":"Montreal Canadiens","amount":666,"time":"2019-02-13T11:00:00.000Z"}`,
+			Event{}, ErrMissingID},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := ParseType(c.eventType, []byte(c.in))
+			if !errors.Is(err, c.err) {
+				t.Errorf("%s, want: %v, got: %v", c.name, c.err, err)
+			}
+			if !reflect.DeepEqual(c.want, got) {
 				t.Errorf("%s, want: %v, got: %v", c.name, c.want, got)
 			}
 		})
 	}
 }
 
+func TestRejection_String(t *testing.T) {
+	cases := []struct {
+		name string
+		in   Rejection
+		want string
+	}{
+		{"with ParseError", Rejection{Line: 3, Err: &ParseError{Kind: ErrEmptyEvent, Detail: "nothing"}},
+			`{"line":3,"error":"empty-event","detail":"nothing"}`},
+		{"without detail", Rejection{Line: 1, Err: &ParseError{Kind: ErrMalformedJSON}}, `{"line":1,"error":"malformed-json"}`},
+		{"with other error", Rejection{Line: 2, Err: errors.New("boom")}, `{"line":2,"error":"boom"}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.in.String(); got != c.want {
+				t.Errorf("%s, want: %s, got: %s", c.name, c.want, got)
+			}
+		})
+	}
+}
+
 func TestTimelineEvent_String(t *testing.T) {
 	cases := []struct {
 		name string
@@ -35,6 +165,17 @@ func TestTimelineEvent_String(t *testing.T) {
 		{"with one violation", tew1Vio, w1Vio},
 		{"with two violation", tew2Vio, w2Vio},
 		{"without violation", tewoVio, woVio},
+		{"with held limit", TimelineEvent{Event: Event{Account: &Account{ActiveCard: true, AvailableLimit: 30, HeldLimit: 70}}},
+			`{"Account":{"active-card":true,"available-limit":30,"held-limit":70},"violations":[]}`},
+		{"with Account ID", TimelineEvent{Event: Event{Account: &Account{ID: "alice", ActiveCard: true, AvailableLimit: 666}}},
+			`{"Account":{"account-id":"alice","active-card":true,"available-limit":666},"violations":[]}`},
+		{"without Account with Account ID", TimelineEvent{Event: Event{Transaction: &Transaction{AccountID: "alice"}},
+			Violations: []Violation{accountNotInitialized}}, `{"Account":{"account-id":"alice"},"violations":["Account-not-initialized"]}`},
+		{"with explanations", TimelineEvent{Event: Event{Account: &Account{ActiveCard: false, AvailableLimit: 10}},
+			Violations:   []Violation{cardNotActive},
+			Explanations: []Explanation{{Violation: cardNotActive, Account: &Account{ActiveCard: false, AvailableLimit: 10}}}},
+			`{"Account":{"active-card":false,"available-limit":10},"violations":["card-not-active"],` +
+				`"explanations":[{"violation":"card-not-active","account":{"active-card":false,"available-limit":10}}]}`},
 	}
 
 	for _, c := range cases {
@@ -75,7 +216,7 @@ var (
 				Time:     datetime(time.Now()),
 			},
 		},
-		Violations: []violation{accountNotInitialized},
+		Violations: []Violation{accountNotInitialized},
 	}
 	woAcc = `{"Account":{},"violations":["Account-not-initialized"]}`
 
@@ -87,7 +228,7 @@ var (
 			},
 			Transaction: nil,
 		},
-		Violations: []violation{accountAlreadyInitialized},
+		Violations: []Violation{accountAlreadyInitialized},
 	}
 	w1Vio = `{"Account":{"active-card":false,"available-limit":666},"violations":["Account-already-initialized"]}`
 
@@ -103,7 +244,7 @@ var (
 				Time:     datetime(time.Now()),
 			},
 		},
-		Violations: []violation{
+		Violations: []Violation{
 			insufficientLimit,
 			doubleTransaction,
 		},
@@ -118,7 +259,7 @@ var (
 			},
 			Transaction: nil,
 		},
-		Violations: make([]violation, 0),
+		Violations: make([]Violation, 0),
 	}
 	woVio = `{"Account":{"active-card":true,"available-limit":666},"violations":[]}`
 )
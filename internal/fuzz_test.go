@@ -0,0 +1,149 @@
+//go:build go1.18
+// +build go1.18
+
+package internal
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// FuzzParse checks that Parse never panics and that each Event it returns is valid and has a single event type.
+func FuzzParse(f *testing.F) {
+	seedLines(f)
+	for _, seed := range []string{
+		`{"account":{"active-card":true,"available-limit":100},"Account":null}`,
+		`{"transaction":{"merchant":"Montreal Canadiens","amount":666,"time":"2019-02-13T11:00:00.000Z"},"authorization":null}`,
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		ie, err := Parse(input)
+		if err != nil {
+			if ie != (Event{}) {
+				t.Errorf("want empty Event with error %v, got: %+v", err, ie)
+			}
+			return
+		}
+		if err := ie.validate(); err != nil {
+			t.Errorf("want valid Event, got: %v", err)
+		}
+		if types := eventTypeCount(ie); types != 1 {
+			t.Errorf("want one event type, got: %d in %+v", types, ie)
+		}
+	})
+}
+
+// FuzzDatetime_UnmarshalJSON checks that every datetime read by UnmarshalJSON is written back by MarshalJSON as the
+// same instant.
+func FuzzDatetime_UnmarshalJSON(f *testing.F) {
+	for _, seed := range []string{`"2019-02-13T11:00:00.000Z"`, `"2019-02-13T11:00:00-03:00"`, `"2019-02-13"`, `null`, `1`, `""`} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var dt datetime
+		if err := dt.UnmarshalJSON(data); err != nil {
+			return
+		}
+
+		out, err := json.Marshal(dt)
+		if err != nil {
+			t.Fatalf("want marshalled %s, got: %v", data, err)
+		}
+		var back datetime
+		if err := back.UnmarshalJSON(out); err != nil {
+			t.Fatalf("want %s read back, got: %v", out, err)
+		}
+		if !time.Time(dt).Equal(time.Time(back)) {
+			t.Errorf("want: %v, got: %v", time.Time(dt), time.Time(back))
+		}
+	})
+}
+
+// FuzzTimeline_Process parses a stream of input lines and processes the valid ones by a single Timeline.
+// The Timeline must never panic, the available limit must never be negative and the violations must never be nil.
+func FuzzTimeline_Process(f *testing.F) {
+	seedFiles(f)
+	f.Add(`{"account": {"active-card": true, "available-limit": 100}}
+{"transaction": {"id": "t1", "merchant": "Miami Marlins", "amount": 10, "time": "2019-02-13T10:00:00.000Z"}}
+{"refund": {"transaction-id": "t1", "amount": 5, "time": "2019-02-13T10:10:00.000Z"}}
+{"refund": {"transaction-id": "t1", "amount": 9223372036854775807, "time": "2019-02-13T10:20:00.000Z"}}`)
+	f.Fuzz(func(t *testing.T, stream string) {
+		timeline := NewTimeline()
+		for _, line := range strings.Split(stream, "\n") {
+			ie, err := Parse(line)
+			if err != nil {
+				continue
+			}
+			timeline.Process(ie)
+
+			te := timeline.Last()
+			if te.Violations == nil {
+				t.Fatalf("want not nil violations, got nil after %s", line)
+			}
+			if acc := timeline.State(); acc != nil && (acc.AvailableLimit < 0 || acc.HeldLimit < 0) {
+				t.Fatalf("want not negative limits, got: %+v after %s", *acc, line)
+			}
+		}
+	})
+}
+
+// seedLines adds each line of the data directory input files into the seed corpus.
+func seedLines(f *testing.F) {
+	for _, path := range dataInputs(f) {
+		file, err := os.Open(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		s := bufio.NewScanner(file)
+		for s.Scan() {
+			f.Add(s.Text())
+		}
+		file.Close()
+	}
+}
+
+// seedFiles adds each input file of the data directory into the seed corpus.
+func seedFiles(f *testing.F) {
+	for _, path := range dataInputs(f) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(data))
+	}
+}
+
+// dataInputs returns the paths of the input files of the data directory, i.e. the ones without .exp suffix.
+func dataInputs(f *testing.F) []string {
+	paths, err := filepath.Glob(filepath.Join("..", "data", "*"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	inputs := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if !strings.HasSuffix(p, ".exp") {
+			inputs = append(inputs, p)
+		}
+	}
+
+	return inputs
+}
+
+// eventTypeCount returns how many event types the Event has.
+func eventTypeCount(ie Event) int {
+	count := 0
+	for _, present := range []bool{ie.Account != nil, ie.Transaction != nil, ie.Card != nil, ie.Limit != nil,
+		ie.Reversal != nil, ie.Refund != nil, ie.Capture != nil, ie.Expired != nil} {
+		if present {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,264 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+)
+
+const (
+	// UniformAmount draws the amounts uniformly between zero and twice the mean.
+	UniformAmount = AmountDistribution("uniform")
+	// ExponentialAmount draws the amounts from an exponential distribution, so most of them are small.
+	ExponentialAmount = AmountDistribution("exponential")
+)
+
+// burstSize is the fewest Transaction of a burst, the most are burstSize+2. Each of them has a distinct Merchant.
+const burstSize = 4
+
+// merchants are the names of the generated Merchant.
+var merchants = []string{
+	"Anaheim Ducks", "Arizona Coyotes", "Boston Bruins", "Buffalo Sabres", "Calgary Flames", "Carolina Hurricanes",
+	"Chicago Blackhawks", "Colorado Avalanche", "Columbus Blue Jackets", "Dallas Stars", "Detroit Red Wings",
+	"Edmonton Oilers", "Florida Panthers", "Los Angeles Kings", "Minnesota Wild", "Montreal Canadiens",
+	"Nashville Predators", "New Jersey Devils", "New York Islanders", "New York Rangers", "Ottawa Senators",
+	"Philadelphia Flyers", "Pittsburgh Penguins", "San Jose Sharks", "Seattle Kraken", "St. Louis Blues",
+	"Tampa Bay Lightning", "Toronto Maple Leafs", "Vancouver Canucks", "Vegas Golden Knights", "Washington Capitals",
+	"Winnipeg Jets",
+}
+
+type (
+	// GeneratorConfig describes a synthetic Event stream written by Generate.
+	// The same GeneratorConfig, including Seed, always generates the same stream.
+	GeneratorConfig struct {
+		// Seed of the random numbers.
+		Seed int64
+		// Accounts is how many Account are initialized. When it is one, the Event do not have Account ID.
+		Accounts int
+		// Events is how many Transaction are generated, including the ones of fraud patterns.
+		Events int
+		// Start is the datetime of the stream beginning.
+		Start time.Time
+		// Interval is the mean interval between two Transaction of the stream, of any Account.
+		Interval time.Duration
+		// Limit is the available limit of each Account.
+		Limit int
+		// Merchants is how many Merchant the Transaction are spread over.
+		Merchants int
+		// MerchantSkew spreads the Transaction over Merchants by a Zipf distribution with this exponent, so a few
+		// Merchant have most of them. It must be greater than one. When it is zero, they are spread uniformly.
+		MerchantSkew float64
+		// Amounts is the distribution of the Transaction amount.
+		Amounts AmountDistribution
+		// MeanAmount is the mean amount of the Transaction that are not part of fraud patterns.
+		MeanAmount int
+		// Bursts is the probability of a Transaction starting a burst of Transaction of the same Account
+		// within seconds, which violates high-frequency-small-interval.
+		Bursts float64
+		// Duplicates is the probability of a Transaction being repeated within seconds, which violates
+		// double-Transaction.
+		Duplicates float64
+		// Exhaustions is the probability of a Transaction starting a series of large Transaction of the same Account,
+		// which violates insufficient-limit.
+		Exhaustions float64
+	}
+	// AmountDistribution is a type created to abstract all constants amount distributions.
+	AmountDistribution string
+
+	// generator keeps the state of a stream being generated.
+	generator struct {
+		GeneratorConfig
+		// r is the source of random numbers.
+		r *rand.Rand
+		// zipf draws the Merchant index when MerchantSkew is not zero.
+		zipf *rand.Zipf
+		// at is the datetime of the last Transaction.
+		at time.Time
+		// left is how many Transaction are still to be generated.
+		left int
+		// enc writes the input lines.
+		enc *json.Encoder
+	}
+	// generatedEvent is an input line of Generate.
+	generatedEvent struct {
+		Account     *Account     `json:"account,omitempty"`
+		Transaction *Transaction `json:"transaction,omitempty"`
+	}
+)
+
+// DefaultGeneratorConfig returns a GeneratorConfig of a small stream of a single Account with a few fraud patterns.
+// It starts at the same datetime of the data directory files.
+func DefaultGeneratorConfig() GeneratorConfig {
+	return GeneratorConfig{
+		Seed:        1,
+		Accounts:    1,
+		Events:      100,
+		Start:       time.Date(2019, time.February, 13, 11, 0, 0, 0, time.UTC),
+		Interval:    time.Minute,
+		Limit:       5000,
+		Merchants:   10,
+		Amounts:     ExponentialAmount,
+		MeanAmount:  30,
+		Bursts:      0.02,
+		Duplicates:  0.02,
+		Exhaustions: 0.01,
+	}
+}
+
+// Validate returns an error wrapping ErrInvalidConfig when the GeneratorConfig could not generate a stream.
+func (gc GeneratorConfig) Validate() error {
+	switch {
+	case gc.Accounts <= 0:
+		return fmt.Errorf("%w: accounts must be positive, got %d", ErrInvalidConfig, gc.Accounts)
+	case gc.Events < 0:
+		return fmt.Errorf("%w: events must not be negative, got %d", ErrInvalidConfig, gc.Events)
+	case gc.Interval <= 0:
+		return fmt.Errorf("%w: interval must be positive, got %s", ErrInvalidConfig, gc.Interval)
+	case gc.Limit < 0:
+		return fmt.Errorf("%w: limit must not be negative, got %d", ErrInvalidConfig, gc.Limit)
+	case gc.Merchants <= 0 || gc.Merchants > len(merchants):
+		return fmt.Errorf("%w: merchants must be between 1 and %d, got %d", ErrInvalidConfig, len(merchants), gc.Merchants)
+	case gc.Bursts > 0 && gc.Merchants < burstSize:
+		return fmt.Errorf("%w: bursts need at least %d merchants, got %d", ErrInvalidConfig, burstSize, gc.Merchants)
+	case gc.MerchantSkew != 0 && gc.MerchantSkew <= 1:
+		return fmt.Errorf("%w: merchant skew must be zero or greater than one, got %g", ErrInvalidConfig, gc.MerchantSkew)
+	case gc.Amounts != UniformAmount && gc.Amounts != ExponentialAmount:
+		return fmt.Errorf("%w: amounts must be %s or %s, got %q", ErrInvalidConfig, UniformAmount, ExponentialAmount, gc.Amounts)
+	case gc.MeanAmount < 0:
+		return fmt.Errorf("%w: mean amount must not be negative, got %d", ErrInvalidConfig, gc.MeanAmount)
+	case gc.Bursts < 0 || gc.Duplicates < 0 || gc.Exhaustions < 0 || gc.Bursts+gc.Duplicates+gc.Exhaustions > 1:
+		return fmt.Errorf("%w: fraud pattern probabilities must not be negative nor add up to more than one, got %g, %g and %g",
+			ErrInvalidConfig, gc.Bursts, gc.Duplicates, gc.Exhaustions)
+	}
+
+	return nil
+}
+
+// Generate writes the newline-delimited input stream described by the GeneratorConfig: the initialization of each
+// Account followed by Transaction in time order.
+// It returns an error wrapping ErrInvalidConfig when the GeneratorConfig is not valid, or the first write error.
+func Generate(w io.Writer, gc GeneratorConfig) error {
+	if err := gc.Validate(); err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	g := &generator{GeneratorConfig: gc, r: rand.New(rand.NewSource(gc.Seed)), at: gc.Start, left: gc.Events, enc: json.NewEncoder(bw)}
+	if gc.MerchantSkew != 0 {
+		g.zipf = rand.NewZipf(g.r, gc.MerchantSkew, 1, uint64(gc.Merchants-1))
+	}
+
+	for i := 0; i < gc.Accounts; i++ {
+		if err := g.enc.Encode(generatedEvent{Account: &Account{ID: g.account(i), ActiveCard: true, AvailableLimit: gc.Limit}}); err != nil {
+			return err
+		}
+	}
+	for g.left > 0 {
+		g.wait(time.Duration(g.r.ExpFloat64() * float64(gc.Interval)))
+		account := g.account(g.r.Intn(gc.Accounts))
+
+		var err error
+		switch p := g.r.Float64(); {
+		case p < gc.Bursts:
+			err = g.burst(account)
+		case p < gc.Bursts+gc.Duplicates:
+			err = g.duplicate(account)
+		case p < gc.Bursts+gc.Duplicates+gc.Exhaustions:
+			err = g.exhaust(account)
+		default:
+			err = g.transaction(account, g.merchant(), g.amount())
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// burst generates a few Transaction of distinct Merchant within seconds. There are at most Merchants of them.
+// The Merchant are drawn uniformly without replacement regardless of MerchantSkew, since a skewed distribution could
+// make distinct Merchant too unlikely to be drawn.
+func (g *generator) burst(account string) error {
+	n := burstSize + g.r.Intn(3)
+	if n > g.Merchants {
+		n = g.Merchants
+	}
+	for _, i := range g.r.Perm(g.Merchants)[:n] {
+		if err := g.transaction(account, merchants[i], g.amount()); err != nil {
+			return err
+		}
+		g.wait(time.Duration(1+g.r.Intn(10)) * time.Second)
+	}
+
+	return nil
+}
+
+// duplicate generates a Transaction and its copy within seconds.
+func (g *generator) duplicate(account string) error {
+	merchant, amount := g.merchant(), g.amount()
+	if err := g.transaction(account, merchant, amount); err != nil {
+		return err
+	}
+	g.wait(time.Duration(1+g.r.Intn(30)) * time.Second)
+
+	return g.transaction(account, merchant, amount)
+}
+
+// exhaust generates a few Transaction of two thirds of the limit, out of the velocity rules windows, so all of them
+// but the first exceed the available limit.
+func (g *generator) exhaust(account string) error {
+	for n := 2 + g.r.Intn(2); n > 0; n-- {
+		if err := g.transaction(account, g.merchant(), g.Limit*2/3+1); err != nil {
+			return err
+		}
+		g.wait(time.Duration(3+g.r.Intn(3)) * time.Minute)
+	}
+
+	return nil
+}
+
+// transaction writes a Transaction at the current datetime, unless all Transaction were already generated.
+func (g *generator) transaction(account, merchant string, amount int) error {
+	if g.left <= 0 {
+		return nil
+	}
+	g.left--
+
+	return g.enc.Encode(generatedEvent{Transaction: &Transaction{AccountID: account, Merchant: merchant, Amount: amount, Time: datetime(g.at)}})
+}
+
+// wait moves the current datetime forward, truncated to milliseconds as the input datetime.
+func (g *generator) wait(d time.Duration) {
+	g.at = g.at.Add(d).Truncate(time.Millisecond)
+}
+
+// account returns the ID of the i-th Account. It is empty when there is a single Account.
+func (g *generator) account(i int) string {
+	if g.Accounts == 1 {
+		return ""
+	}
+
+	return fmt.Sprintf("account-%d", i+1)
+}
+
+// merchant draws one of the first Merchants merchants according MerchantSkew.
+func (g *generator) merchant() string {
+	if g.zipf != nil {
+		return merchants[g.zipf.Uint64()]
+	}
+
+	return merchants[g.r.Intn(g.Merchants)]
+}
+
+// amount draws a Transaction amount according Amounts.
+func (g *generator) amount() int {
+	if g.Amounts == UniformAmount {
+		return g.r.Intn(2*g.MeanAmount + 1)
+	}
+
+	return int(g.r.ExpFloat64() * float64(g.MeanAmount))
+}
@@ -0,0 +1,162 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerate(t *testing.T) {
+	gc := DefaultGeneratorConfig()
+	gc.Accounts, gc.Events, gc.MerchantSkew = 5, 1000, 1.5
+	out := generate(t, gc)
+
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if want := gc.Accounts + gc.Events; len(lines) != want {
+		t.Fatalf("want: %d lines, got: %d", want, len(lines))
+	}
+	accounts := make(map[string]bool)
+	last := gc.Start
+	for i, line := range lines {
+		ie, err := Parse(line)
+		if err != nil {
+			t.Fatalf("line %d, want valid event, got: %v", i+1, err)
+		}
+		if i < gc.Accounts {
+			if ie.Account == nil || ie.Account.AvailableLimit != gc.Limit || accounts[ie.Account.ID] {
+				t.Fatalf("line %d, want initialization of a new account, got: %s", i+1, line)
+			}
+			accounts[ie.Account.ID] = true
+			continue
+		}
+		if ie.Transaction == nil || !accounts[ie.AccountID] {
+			t.Fatalf("line %d, want transaction of an initialized account, got: %s", i+1, line)
+		}
+		if at := time.Time(ie.Transaction.Time); at.Before(last) {
+			t.Fatalf("line %d, want time at or after %v, got: %v", i+1, last, at)
+		} else {
+			last = at
+		}
+	}
+}
+
+func TestGenerate_Seed(t *testing.T) {
+	gc := DefaultGeneratorConfig()
+	first, second := generate(t, gc), generate(t, gc)
+	if first != second {
+		t.Errorf("want same stream with the same seed, got:\n%s\n%s", first, second)
+	}
+
+	gc.Seed++
+	if other := generate(t, gc); first == other {
+		t.Errorf("want another stream with another seed, got the same:\n%s", first)
+	}
+}
+
+func TestGenerate_Patterns(t *testing.T) {
+	cases := []struct {
+		name    string
+		pattern func(*GeneratorConfig)
+		want    Violation
+	}{
+		{"bursts", func(gc *GeneratorConfig) { gc.Bursts = 1 }, highFrequency},
+		{"bursts of skewed merchants", func(gc *GeneratorConfig) { gc.Bursts, gc.MerchantSkew = 1, 20 }, highFrequency},
+		{"duplicates", func(gc *GeneratorConfig) { gc.Duplicates = 1 }, doubleTransaction},
+		{"exhaustions", func(gc *GeneratorConfig) { gc.Exhaustions = 1 }, insufficientLimit},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gc := DefaultGeneratorConfig()
+			gc.Events, gc.Bursts, gc.Duplicates, gc.Exhaustions = 10, 0, 0, 0
+			c.pattern(&gc)
+
+			got := sequential(generate(t, gc))
+			if !strings.Contains(got, `"`+string(c.want)+`"`) {
+				t.Errorf("%s, want: %s, got:\n%s", c.name, c.want, got)
+			}
+		})
+	}
+}
+
+// TestGenerate_Merchants checks that all Transaction, including the ones of fraud patterns, have one of the first
+// Merchants merchants.
+func TestGenerate_Merchants(t *testing.T) {
+	cases := []struct {
+		name   string
+		change func(*GeneratorConfig)
+	}{
+		{"uniform", func(gc *GeneratorConfig) {}},
+		{"skewed", func(gc *GeneratorConfig) { gc.MerchantSkew = 2 }},
+		{"highly skewed", func(gc *GeneratorConfig) { gc.MerchantSkew = 20 }},
+		{"as many as a burst", func(gc *GeneratorConfig) { gc.Merchants = 4 }},
+		{"without bursts", func(gc *GeneratorConfig) { gc.Merchants, gc.Bursts = 1, 0 }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gc := DefaultGeneratorConfig()
+			gc.Events, gc.Merchants, gc.Bursts, gc.Duplicates, gc.Exhaustions = 1000, 5, 0.3, 0.3, 0.3
+			c.change(&gc)
+
+			configured := make(map[string]bool, gc.Merchants)
+			for _, m := range merchants[:gc.Merchants] {
+				configured[m] = true
+			}
+			for i, line := range strings.Split(strings.TrimSuffix(generate(t, gc), "\n"), "\n") {
+				if ie, err := Parse(line); err == nil && ie.Transaction != nil && !configured[ie.Merchant] {
+					t.Fatalf("%s, line %d, want one of %v, got: %s", c.name, i+1, merchants[:gc.Merchants], ie.Merchant)
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratorConfig_Validate(t *testing.T) {
+	cases := []struct {
+		name   string
+		change func(*GeneratorConfig)
+		want   string
+	}{
+		{"without accounts", func(gc *GeneratorConfig) { gc.Accounts = 0 }, "accounts must be positive"},
+		{"negative events", func(gc *GeneratorConfig) { gc.Events = -1 }, "events must not be negative"},
+		{"without interval", func(gc *GeneratorConfig) { gc.Interval = 0 }, "interval must be positive"},
+		{"negative limit", func(gc *GeneratorConfig) { gc.Limit = -1 }, "limit must not be negative"},
+		{"too many merchants", func(gc *GeneratorConfig) { gc.Merchants = 100 }, "merchants must be between 1 and 32"},
+		{"low merchant skew", func(gc *GeneratorConfig) { gc.MerchantSkew = 0.5 }, "merchant skew must be zero or greater than one"},
+		{"unknown amounts", func(gc *GeneratorConfig) { gc.Amounts = "normal" }, `amounts must be uniform or exponential, got "normal"`},
+		{"negative mean amount", func(gc *GeneratorConfig) { gc.MeanAmount = -1 }, "mean amount must not be negative"},
+		{"patterns over one", func(gc *GeneratorConfig) { gc.Bursts, gc.Duplicates = 0.6, 0.6 }, "fraud pattern probabilities"},
+		{"bursts with few merchants", func(gc *GeneratorConfig) { gc.Merchants = 3 }, "bursts need at least 4 merchants, got 3"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gc := DefaultGeneratorConfig()
+			c.change(&gc)
+			err := Generate(&bytes.Buffer{}, gc)
+			if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), c.want) {
+				t.Errorf("%s, want: %s, got: %v", c.name, c.want, err)
+			}
+		})
+	}
+}
+
+func TestGenerate_WriteError(t *testing.T) {
+	if err := Generate(failingWriter{}, DefaultGeneratorConfig()); err == nil {
+		t.Errorf("want write error, got nil")
+	}
+}
+
+// generate returns the stream of the GeneratorConfig.
+func generate(t *testing.T, gc GeneratorConfig) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := Generate(&out, gc); err != nil {
+		t.Fatal(err)
+	}
+
+	return out.String()
+}
@@ -0,0 +1,196 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"github.com/r1cm3d/authorizer/internal/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// violationCodes maps each built-in Violation into its pb.Violation.
+// Violations of custom rules are not there, they are mapped into pb.Violation_VIOLATION_UNSPECIFIED.
+var violationCodes = map[Violation]pb.Violation{
+	accountAlreadyInitialized: pb.Violation_ACCOUNT_ALREADY_INITIALIZED,
+	accountNotInitialized:     pb.Violation_ACCOUNT_NOT_INITIALIZED,
+	cardNotActive:             pb.Violation_CARD_NOT_ACTIVE,
+	insufficientLimit:         pb.Violation_INSUFFICIENT_LIMIT,
+	highFrequency:             pb.Violation_HIGH_FREQUENCY_SMALL_INTERVAL,
+	doubleTransaction:         pb.Violation_DOUBLE_TRANSACTION,
+	cardAlreadyActive:         pb.Violation_CARD_ALREADY_ACTIVE,
+	cardAlreadyBlocked:        pb.Violation_CARD_ALREADY_BLOCKED,
+	negativeLimit:             pb.Violation_NEGATIVE_AVAILABLE_LIMIT,
+	unknownTransaction:        pb.Violation_UNKNOWN_TRANSACTION,
+	alreadyReversed:           pb.Violation_TRANSACTION_ALREADY_REVERSED,
+	refundExceedsAmount:       pb.Violation_REFUND_EXCEEDS_AMOUNT,
+	unknownAuthorization:      pb.Violation_UNKNOWN_AUTHORIZATION,
+	captureExceedsAmount:      pb.Violation_CAPTURE_EXCEEDS_AUTHORIZATION,
+	transactionIDConflict:     pb.Violation_TRANSACTION_ID_CONFLICT,
+	lateEvent:                 pb.Violation_LATE_EVENT,
+}
+
+// errorKinds maps the ParseError kinds of invalid pb.Event into their pb.ErrorKind.
+// Other kinds are mapped into pb.ErrorKind_ERROR_KIND_UNSPECIFIED.
+var errorKinds = map[error]pb.ErrorKind{
+	ErrEmptyEvent:     pb.ErrorKind_EMPTY_EVENT,
+	ErrInvalidTime:    pb.ErrorKind_INVALID_TIME,
+	ErrNegativeAmount: pb.ErrorKind_NEGATIVE_AMOUNT,
+}
+
+type (
+	// GRPCServer exposes a ConcurrentAuthorizer through the gRPC Authorizer service of proto/authorizer.proto.
+	// Processed Events are answered with a pb.Decision, even when it has violations.
+	// Requests that are not valid Events are answered with codes.InvalidArgument and the ParseError as message, except
+	// the ones of a stream, which are answered with a pb.Decision that has a pb.Rejection, so the stream goes on.
+	GRPCServer struct {
+		pb.UnimplementedAuthorizerServer
+		// authorizer processes the Events of all requests.
+		authorizer *ConcurrentAuthorizer
+	}
+)
+
+// NewGRPCServer creates a new GRPCServer backed by the given ConcurrentAuthorizer.
+func NewGRPCServer(authorizer *ConcurrentAuthorizer) *GRPCServer {
+	return &GRPCServer{authorizer: authorizer}
+}
+
+// Serve serves the requests of the listener until ctx is done. Then it shuts down gracefully: it stops accepting
+// connections and waits up to drain for the in-flight RPCs, including streams, before closing them.
+func (s *GRPCServer) Serve(ctx context.Context, l net.Listener, drain time.Duration) error {
+	srv := grpc.NewServer()
+	pb.RegisterAuthorizerServer(srv, s)
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(l)
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(drain):
+		srv.Stop()
+	}
+
+	return <-served
+}
+
+// InitAccount implements pb.AuthorizerServer interface.
+func (s *GRPCServer) InitAccount(_ context.Context, acc *pb.Account) (*pb.Decision, error) {
+	return s.process(&pb.Event{Event: &pb.Event_Account{Account: acc}})
+}
+
+// Authorize implements pb.AuthorizerServer interface.
+func (s *GRPCServer) Authorize(_ context.Context, tr *pb.Transaction) (*pb.Decision, error) {
+	return s.process(&pb.Event{Event: &pb.Event_Transaction{Transaction: tr}})
+}
+
+// ProcessEvents implements pb.AuthorizerServer interface.
+// Events that are not valid are answered with a pb.Rejection, as the rejections of the standard input, and the stream
+// goes on.
+func (s *GRPCServer) ProcessEvents(stream pb.Authorizer_ProcessEventsServer) error {
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var d *pb.Decision
+		if ie, err := fromProto(in); err != nil {
+			d = &pb.Decision{Rejection: toRejection(err)}
+		} else {
+			d = toProto(s.authorizer.Process(ie))
+		}
+		if err := stream.Send(d); err != nil {
+			return err
+		}
+	}
+}
+
+// process converts the pb.Event, processes it and converts the resulting TimelineEvent into a pb.Decision.
+func (s *GRPCServer) process(in *pb.Event) (*pb.Decision, error) {
+	ie, err := fromProto(in)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return toProto(s.authorizer.Process(ie)), nil
+}
+
+// fromProto converts the pb.Event into an Event.
+// It returns a *ParseError when the pb.Event is not a valid Event.
+func fromProto(in *pb.Event) (Event, error) {
+	var ie Event
+	switch e := in.GetEvent().(type) {
+	case *pb.Event_Account:
+		ie.Account = &Account{
+			ID:             e.Account.GetAccountId(),
+			ActiveCard:     e.Account.GetActiveCard(),
+			AvailableLimit: int(e.Account.GetAvailableLimit()),
+		}
+	case *pb.Event_Transaction:
+		if e.Transaction.GetTime() == nil {
+			return Event{}, &ParseError{Kind: ErrInvalidTime, Detail: "time is required"}
+		}
+		ie.Transaction = &Transaction{
+			ID:        e.Transaction.GetId(),
+			AccountID: e.Transaction.GetAccountId(),
+			Merchant:  e.Transaction.GetMerchant(),
+			Amount:    int(e.Transaction.GetAmount()),
+			Time:      datetime(e.Transaction.GetTime().AsTime()),
+		}
+	default:
+		return Event{}, &ParseError{Kind: ErrEmptyEvent, Detail: "event has no event type"}
+	}
+
+	if err := ie.validate(); err != nil {
+		return Event{}, err
+	}
+
+	return ie, nil
+}
+
+// toProto converts the TimelineEvent into a pb.Decision.
+func toProto(te TimelineEvent) *pb.Decision {
+	d := &pb.Decision{AccountId: te.accountID(), Violations: make([]pb.Violation, 0, len(te.Violations))}
+	if te.Account != nil {
+		d.Account = &pb.Account{
+			AccountId:      te.Account.ID,
+			ActiveCard:     te.ActiveCard,
+			AvailableLimit: int64(te.AvailableLimit),
+			HeldLimit:      int64(te.HeldLimit),
+		}
+	}
+	for _, v := range te.Violations {
+		d.Violations = append(d.Violations, violationCodes[v])
+	}
+
+	return d
+}
+
+// toRejection converts the error of fromProto into a pb.Rejection with its kind and detail.
+func toRejection(err error) *pb.Rejection {
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		return &pb.Rejection{Detail: err.Error()}
+	}
+
+	return &pb.Rejection{Error: errorKinds[pe.Kind], Detail: pe.Detail}
+}
@@ -0,0 +1,199 @@
+package internal
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/r1cm3d/authorizer/internal/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestGRPCServer_Unary(t *testing.T) {
+	client := grpcClient(t, newConcurrentAuthorizer(t))
+	ctx := context.Background()
+	at := timestamppb.New(time.Time(trTime))
+
+	d, err := client.Authorize(ctx, &pb.Transaction{AccountId: "alice", Merchant: "Buffalo Sabres", Amount: 20, Time: at})
+	assertDecision(t, d, err, &pb.Decision{AccountId: "alice", Violations: []pb.Violation{pb.Violation_ACCOUNT_NOT_INITIALIZED}})
+
+	d, err = client.InitAccount(ctx, &pb.Account{AccountId: "alice", ActiveCard: true, AvailableLimit: 100})
+	assertDecision(t, d, err, &pb.Decision{
+		AccountId:  "alice",
+		Account:    &pb.Account{AccountId: "alice", ActiveCard: true, AvailableLimit: 100},
+		Violations: []pb.Violation{},
+	})
+
+	d, err = client.Authorize(ctx, &pb.Transaction{AccountId: "alice", Merchant: "Buffalo Sabres", Amount: 120, Time: at})
+	assertDecision(t, d, err, &pb.Decision{
+		AccountId:  "alice",
+		Account:    &pb.Account{AccountId: "alice", ActiveCard: true, AvailableLimit: 100},
+		Violations: []pb.Violation{pb.Violation_INSUFFICIENT_LIMIT},
+	})
+
+	d, err = client.Authorize(ctx, &pb.Transaction{AccountId: "alice", Merchant: "Buffalo Sabres", Amount: 20, Time: at})
+	assertDecision(t, d, err, &pb.Decision{
+		AccountId:  "alice",
+		Account:    &pb.Account{AccountId: "alice", ActiveCard: true, AvailableLimit: 80},
+		Violations: []pb.Violation{},
+	})
+}
+
+func TestGRPCServer_Unary_Invalid(t *testing.T) {
+	client := grpcClient(t, newConcurrentAuthorizer(t))
+	ctx := context.Background()
+
+	cases := []struct {
+		name string
+		call func() (*pb.Decision, error)
+		want string
+	}{
+		{"negative limit", func() (*pb.Decision, error) {
+			return client.InitAccount(ctx, &pb.Account{ActiveCard: true, AvailableLimit: -1})
+		}, "negative-amount: available-limit must not be negative"},
+		{"negative amount", func() (*pb.Decision, error) {
+			return client.Authorize(ctx, &pb.Transaction{Merchant: "Buffalo Sabres", Amount: -1, Time: timestamppb.Now()})
+		}, "negative-amount: amount must not be negative"},
+		{"without time", func() (*pb.Decision, error) {
+			return client.Authorize(ctx, &pb.Transaction{Merchant: "Buffalo Sabres", Amount: 1})
+		}, "invalid-time: time is required"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := c.call()
+			if s := status.Convert(err); s.Code() != codes.InvalidArgument || s.Message() != c.want {
+				t.Errorf("%s, want: %v %s, got: %v", c.name, codes.InvalidArgument, c.want, err)
+			}
+		})
+	}
+}
+
+func TestGRPCServer_ProcessEvents(t *testing.T) {
+	client := grpcClient(t, newConcurrentAuthorizer(t))
+	stream, err := client.ProcessEvents(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transaction := func(minute int) *pb.Event {
+		return &pb.Event{Event: &pb.Event_Transaction{Transaction: &pb.Transaction{
+			Merchant: "Buffalo Sabres", Amount: 10, Time: timestamppb.New(time.Time(trTime).Add(time.Duration(minute) * time.Minute)),
+		}}}
+	}
+	in := []*pb.Event{
+		{Event: &pb.Event_Account{Account: &pb.Account{ActiveCard: true, AvailableLimit: 100}}},
+		transaction(0),
+		transaction(1),
+		{Event: &pb.Event_Account{Account: &pb.Account{ActiveCard: false, AvailableLimit: 10}}},
+	}
+	want := []*pb.Decision{
+		{Account: &pb.Account{ActiveCard: true, AvailableLimit: 100}, Violations: []pb.Violation{}},
+		{Account: &pb.Account{ActiveCard: true, AvailableLimit: 90}, Violations: []pb.Violation{}},
+		{Account: &pb.Account{ActiveCard: true, AvailableLimit: 90}, Violations: []pb.Violation{pb.Violation_DOUBLE_TRANSACTION}},
+		{Account: &pb.Account{ActiveCard: true, AvailableLimit: 90}, Violations: []pb.Violation{pb.Violation_ACCOUNT_ALREADY_INITIALIZED}},
+	}
+
+	for i, ie := range in {
+		if err := stream.Send(ie); err != nil {
+			t.Fatal(err)
+		}
+		d, err := stream.Recv()
+		assertDecision(t, d, err, want[i])
+	}
+
+	rejected := []struct {
+		in   *pb.Event
+		want *pb.Rejection
+	}{
+		{&pb.Event{}, &pb.Rejection{Error: pb.ErrorKind_EMPTY_EVENT, Detail: "event has no event type"}},
+		{&pb.Event{Event: &pb.Event_Transaction{Transaction: &pb.Transaction{Merchant: "Buffalo Sabres", Amount: 10}}},
+			&pb.Rejection{Error: pb.ErrorKind_INVALID_TIME, Detail: "time is required"}},
+	}
+	for _, r := range rejected {
+		if err := stream.Send(r.in); err != nil {
+			t.Fatal(err)
+		}
+		d, err := stream.Recv()
+		assertDecision(t, d, err, &pb.Decision{Rejection: r.want})
+	}
+
+	if err := stream.Send(transaction(5)); err != nil {
+		t.Fatal(err)
+	}
+	d, err := stream.Recv()
+	assertDecision(t, d, err, &pb.Decision{Account: &pb.Account{ActiveCard: true, AvailableLimit: 80}, Violations: []pb.Violation{}})
+}
+
+func TestGRPCServer_ProcessEvents_CloseSend(t *testing.T) {
+	client := grpcClient(t, newConcurrentAuthorizer(t))
+	stream, err := client.ProcessEvents(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Errorf("want: %v, got: %v", io.EOF, err)
+	}
+}
+
+func TestToProto_CustomViolation(t *testing.T) {
+	te := TimelineEvent{Event: Event{Account: &Account{ID: "alice"}}, Violations: []Violation{"weekend", cardNotActive}}
+	want := &pb.Decision{
+		AccountId:  "alice",
+		Account:    &pb.Account{AccountId: "alice"},
+		Violations: []pb.Violation{pb.Violation_VIOLATION_UNSPECIFIED, pb.Violation_CARD_NOT_ACTIVE},
+	}
+
+	if got := toProto(te); !proto.Equal(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
+
+// grpcClient serves a GRPCServer backed by the ConcurrentAuthorizer in-process and returns a client connected to it.
+// Both are stopped when the test finishes.
+func grpcClient(t *testing.T, authorizer *ConcurrentAuthorizer) pb.AuthorizerClient {
+	l := bufconn.Listen(1 << 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- NewGRPCServer(authorizer).Serve(ctx, l, time.Second)
+	}()
+
+	conn, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return l.Dial() }),
+		grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+		if err := <-served; err != nil {
+			t.Errorf("want: nil, got: %v", err)
+		}
+	})
+
+	return pb.NewAuthorizerClient(conn)
+}
+
+// assertDecision checks that the RPC did not fail and answered the wanted pb.Decision.
+func assertDecision(t *testing.T, got *pb.Decision, err error, want *pb.Decision) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("want no error, got: %v", err)
+	}
+	if !proto.Equal(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
@@ -0,0 +1,218 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: authorizer.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// AuthorizerClient is the client API for Authorizer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AuthorizerClient interface {
+	// InitAccount initializes an account.
+	InitAccount(ctx context.Context, in *Account, opts ...grpc.CallOption) (*Decision, error)
+	// Authorize validates a transaction and debits its amount when it is valid.
+	Authorize(ctx context.Context, in *Transaction, opts ...grpc.CallOption) (*Decision, error)
+	// ProcessEvents processes a stream of events and answers one decision per event, in order.
+	// Invalid events are answered with a rejection and the stream goes on.
+	ProcessEvents(ctx context.Context, opts ...grpc.CallOption) (Authorizer_ProcessEventsClient, error)
+}
+
+type authorizerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAuthorizerClient(cc grpc.ClientConnInterface) AuthorizerClient {
+	return &authorizerClient{cc}
+}
+
+func (c *authorizerClient) InitAccount(ctx context.Context, in *Account, opts ...grpc.CallOption) (*Decision, error) {
+	out := new(Decision)
+	err := c.cc.Invoke(ctx, "/authorizer.v1.Authorizer/InitAccount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authorizerClient) Authorize(ctx context.Context, in *Transaction, opts ...grpc.CallOption) (*Decision, error) {
+	out := new(Decision)
+	err := c.cc.Invoke(ctx, "/authorizer.v1.Authorizer/Authorize", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authorizerClient) ProcessEvents(ctx context.Context, opts ...grpc.CallOption) (Authorizer_ProcessEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Authorizer_ServiceDesc.Streams[0], "/authorizer.v1.Authorizer/ProcessEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &authorizerProcessEventsClient{stream}
+	return x, nil
+}
+
+type Authorizer_ProcessEventsClient interface {
+	Send(*Event) error
+	Recv() (*Decision, error)
+	grpc.ClientStream
+}
+
+type authorizerProcessEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *authorizerProcessEventsClient) Send(m *Event) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *authorizerProcessEventsClient) Recv() (*Decision, error) {
+	m := new(Decision)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AuthorizerServer is the server API for Authorizer service.
+// All implementations must embed UnimplementedAuthorizerServer
+// for forward compatibility
+type AuthorizerServer interface {
+	// InitAccount initializes an account.
+	InitAccount(context.Context, *Account) (*Decision, error)
+	// Authorize validates a transaction and debits its amount when it is valid.
+	Authorize(context.Context, *Transaction) (*Decision, error)
+	// ProcessEvents processes a stream of events and answers one decision per event, in order.
+	// Invalid events are answered with a rejection and the stream goes on.
+	ProcessEvents(Authorizer_ProcessEventsServer) error
+	mustEmbedUnimplementedAuthorizerServer()
+}
+
+// UnimplementedAuthorizerServer must be embedded to have forward compatible implementations.
+type UnimplementedAuthorizerServer struct {
+}
+
+func (UnimplementedAuthorizerServer) InitAccount(context.Context, *Account) (*Decision, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InitAccount not implemented")
+}
+func (UnimplementedAuthorizerServer) Authorize(context.Context, *Transaction) (*Decision, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Authorize not implemented")
+}
+func (UnimplementedAuthorizerServer) ProcessEvents(Authorizer_ProcessEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ProcessEvents not implemented")
+}
+func (UnimplementedAuthorizerServer) mustEmbedUnimplementedAuthorizerServer() {}
+
+// UnsafeAuthorizerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AuthorizerServer will
+// result in compilation errors.
+type UnsafeAuthorizerServer interface {
+	mustEmbedUnimplementedAuthorizerServer()
+}
+
+func RegisterAuthorizerServer(s grpc.ServiceRegistrar, srv AuthorizerServer) {
+	s.RegisterService(&Authorizer_ServiceDesc, srv)
+}
+
+func _Authorizer_InitAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Account)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthorizerServer).InitAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/authorizer.v1.Authorizer/InitAccount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthorizerServer).InitAccount(ctx, req.(*Account))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Authorizer_Authorize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Transaction)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthorizerServer).Authorize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/authorizer.v1.Authorizer/Authorize",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthorizerServer).Authorize(ctx, req.(*Transaction))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Authorizer_ProcessEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AuthorizerServer).ProcessEvents(&authorizerProcessEventsServer{stream})
+}
+
+type Authorizer_ProcessEventsServer interface {
+	Send(*Decision) error
+	Recv() (*Event, error)
+	grpc.ServerStream
+}
+
+type authorizerProcessEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *authorizerProcessEventsServer) Send(m *Decision) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *authorizerProcessEventsServer) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Authorizer_ServiceDesc is the grpc.ServiceDesc for Authorizer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Authorizer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "authorizer.v1.Authorizer",
+	HandlerType: (*AuthorizerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "InitAccount",
+			Handler:    _Authorizer_InitAccount_Handler,
+		},
+		{
+			MethodName: "Authorize",
+			Handler:    _Authorizer_Authorize_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ProcessEvents",
+			Handler:       _Authorizer_ProcessEvents_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "authorizer.proto",
+}
@@ -0,0 +1,328 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// Pipeline processes a stream of input lines in parallel and writes the results in the input order.
+	// Lines are parsed by a pool of goroutines and each Event is routed by the hash of its Account ID to one of the
+	// workers, so every Account is owned by a single worker and its Events keep their relative order.
+	// The state of the workers outlives Run, so later calls resume where the previous one stopped.
+	Pipeline struct {
+		// authorizers has the Authorizer of each worker.
+		authorizers []*Authorizer
+		// log receives each parsed Event before it is processed. It is nil when the Pipeline has no WAL.
+		log *WAL
+		// now is the datetime of the latest Event of all workers. Each Event is processed after its worker is advanced
+		// to it, so the authorizations expire the same way whatever the number of workers, see Authorizer.Advance.
+		now time.Time
+	}
+	// job is an input line travelling through the Pipeline.
+	job struct {
+		// line is the 1-based line number of the input.
+		line int
+		// input is the raw text of the line.
+		input string
+		// parsed receives the Event, or the error, returned by Parse.
+		parsed chan parsed
+		// out receives the output of the job, a line per TimelineEvent it decided.
+		out chan string
+	}
+	// parsed is the result of parsing a job.
+	parsed struct {
+		event Event
+		err   error
+	}
+	// task is a parsed Event routed to a worker.
+	task struct {
+		event Event
+		out   chan string
+		// now is the datetime of the latest Event of all workers when the Event was dispatched, see Pipeline.
+		now time.Time
+	}
+)
+
+// NewPipeline creates a new Pipeline with the given number of workers that calls newTimeline to create the Timeline
+// of each new Account. A Pipeline with less than one worker has a single one.
+func NewPipeline(workers int, newTimeline func() Timeline) *Pipeline {
+	if workers < 1 {
+		workers = 1
+	}
+
+	authorizers := make([]*Authorizer, workers)
+	for i := range authorizers {
+		authorizers[i] = NewAuthorizer(newTimeline)
+	}
+
+	return &Pipeline{authorizers: authorizers}
+}
+
+// Log makes the Pipeline append each parsed Event to the WAL before processing it, so its result is only emitted
+// once it is logged.
+func (p *Pipeline) Log(l *WAL) {
+	p.log = l
+}
+
+// Replay processes the Event in the worker of its Account without emitting any output, e.g. to restore the state
+// from a WAL, see Replayer. It must not be called while Run is running.
+func (p *Pipeline) Replay(ie Event) {
+	a := p.authorizers[shard(ie.accountID(), len(p.authorizers))]
+	a.Advance(p.advance(ie))
+	a.Process(ie)
+}
+
+// ReplayFlush decides the Event buffered by the replayed ones, see Timeline.Flush, without emitting any output, since
+// the Run that logged the flush wrote them when its input ended. The Event buffered after the last logged flush, e.g.
+// by a Run that crashed, stay buffered, so the next Run writes them. It must not be called while Run is running.
+func (p *Pipeline) ReplayFlush() {
+	p.flush()
+}
+
+// Snapshot returns the Snapshot of the Timeline of all workers with the given Config, which must be the one of the
+// Timeline. When the Pipeline has a WAL, the Snapshot has its sequence number. It must not be called while Run is
+// running.
+func (p *Pipeline) Snapshot(cfg Config) Snapshot {
+	s := Snapshot{Version: SnapshotVersion, Config: cfg, Accounts: make(map[string]TimelineSnapshot)}
+	if p.log != nil {
+		s.WALSequence = p.log.Sequence()
+	}
+	for _, a := range p.authorizers {
+		a.Snapshot(s.Accounts)
+	}
+
+	return s
+}
+
+// Restore replaces the Timeline of each Account of the Snapshot in the worker of the Account. It must not be called
+// while Run is running.
+func (p *Pipeline) Restore(s Snapshot) {
+	for id, ts := range s.Accounts {
+		p.authorizers[shard(id, len(p.authorizers))].Restore(id, ts)
+		if now := time.Time(ts.Now); now.After(p.now) {
+			p.now = now
+		}
+	}
+	for _, a := range p.authorizers {
+		a.Advance(p.now)
+	}
+}
+
+// Run reads the lines of r until EOF, processes them and writes one output line per input line to w, in the input
+// order. The output of a line is a Rejection when it cannot be parsed or it is longer than maxBodySize, otherwise it is
+// the resulting TimelineEvent. Long lines are discarded without being buffered.
+// When the late policy is LateReorder, the output of a line has the TimelineEvent decided by it instead, which could
+// be none or many, and the Event still buffered at EOF are decided and written at the end, see flush. Those include
+// the Event replayed after the last flush of the WAL, if any, and the new flush is logged before they are written.
+// It returns the first error appending to the WAL, reading r or writing w. Once the WAL fails, no more lines are
+// processed nor written.
+func (p *Pipeline) Run(r io.Reader, w io.Writer) error {
+	workers := len(p.authorizers)
+	buffer := 64 * workers
+	parse := make(chan *job, buffer)
+	dispatch := make(chan *job, buffer)
+	write := make(chan chan string, buffer)
+	shards := make([]chan task, workers)
+
+	var wg sync.WaitGroup
+	for i := range shards {
+		shards[i] = make(chan task, buffer)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			parseLines(parse)
+		}()
+		go func(tasks <-chan task, authorizer *Authorizer) {
+			defer wg.Done()
+			process(tasks, authorizer)
+		}(shards[i], p.authorizers[i])
+	}
+	logged := make(chan error, 1)
+	go func() {
+		logged <- p.dispatch(dispatch, shards, write)
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- writeLines(w, write)
+	}()
+
+	br := bufio.NewReader(r)
+	var rerr error
+	for line := 1; rerr == nil; line++ {
+		var input string
+		input, rerr = readLine(br)
+		if input == "" && rerr != nil && rerr != errLineTooLong {
+			break
+		}
+		j := &job{line: line, parsed: make(chan parsed, 1), out: make(chan string, 1)}
+		if rerr == errLineTooLong {
+			j.parsed <- parsed{err: rerr}
+			rerr = nil
+		} else {
+			j.input = strings.TrimSuffix(strings.TrimSuffix(input, "\n"), "\r")
+			parse <- j
+		}
+		dispatch <- j
+	}
+	close(parse)
+	close(dispatch)
+
+	err := <-done
+	wg.Wait()
+	if lerr := <-logged; lerr != nil {
+		return lerr
+	}
+	if err != nil {
+		return err
+	}
+	if rerr != nil && rerr != io.EOF {
+		return rerr
+	}
+
+	if p.log != nil && p.buffered() {
+		if err := p.log.AppendFlush(); err != nil {
+			return err
+		}
+	}
+	for _, te := range p.flush() {
+		if _, err := fmt.Fprintln(w, te.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buffered returns true when any Timeline has Event buffered by LateReorder.
+func (p *Pipeline) buffered() bool {
+	for _, a := range p.authorizers {
+		for _, t := range a.timelines {
+			if len(t.pending) > 0 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// flush decides the Event buffered by all Timeline, see Timeline.Flush, and returns their TimelineEvent in the
+// order of their Account ID, so the output does not depend on the number of workers.
+func (p *Pipeline) flush() []TimelineEvent {
+	ids := make([]string, 0)
+	for _, a := range p.authorizers {
+		for id := range a.timelines {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	decided := make([]TimelineEvent, 0)
+	for _, id := range ids {
+		t, _ := p.authorizers[shard(id, len(p.authorizers))].Timeline(id)
+		t.Flush()
+		decided = append(decided, t.Decided()...)
+	}
+
+	return decided
+}
+
+// parseLines parses each job until the channel is closed.
+func parseLines(jobs <-chan *job) {
+	for j := range jobs {
+		event, err := Parse(j.input)
+		j.parsed <- parsed{event: event, err: err}
+	}
+}
+
+// dispatch waits for each job to be parsed, in the input order, logs its Event and routes it to the worker of its
+// Account. Rejections are answered right away. The output channels are forwarded to write in the same order.
+// After the first error appending to the WAL, the output channels are closed without output and the error is
+// returned.
+func (p *Pipeline) dispatch(jobs <-chan *job, shards []chan task, write chan<- chan string) error {
+	var err error
+	for j := range jobs {
+		pr := <-j.parsed
+		switch {
+		case err != nil:
+			close(j.out)
+		case pr.err != nil:
+			j.out <- Rejection{Line: j.line, Err: pr.err}.String()
+		default:
+			if p.log != nil {
+				err = p.log.Append(j.input)
+			}
+			if err != nil {
+				close(j.out)
+			} else {
+				shards[shard(pr.event.accountID(), len(shards))] <- task{event: pr.event, out: j.out, now: p.advance(pr.event)}
+			}
+		}
+		write <- j.out
+	}
+
+	for _, s := range shards {
+		close(s)
+	}
+	close(write)
+
+	return err
+}
+
+// process runs the tasks of a worker against its Authorizer until the channel is closed.
+// The output of a task has a line per decided TimelineEvent.
+func process(tasks <-chan task, authorizer *Authorizer) {
+	for t := range tasks {
+		authorizer.Advance(t.now)
+		authorizer.Process(t.event)
+		decided := authorizer.Decided()
+		if len(decided) == 1 {
+			t.out <- decided[0].String()
+			continue
+		}
+		lines := make([]string, len(decided))
+		for i, te := range decided {
+			lines[i] = te.String()
+		}
+		t.out <- strings.Join(lines, "\n")
+	}
+}
+
+// writeLines writes each output as soon as it is ready, in the order they were received. Closed output channels and
+// empty outputs are skipped. After a write error it keeps draining the outputs, so the Pipeline is never blocked.
+func writeLines(w io.Writer, outs <-chan chan string) error {
+	var err error
+	for out := range outs {
+		line, ok := <-out
+		if ok && line != "" && err == nil {
+			_, err = fmt.Fprintln(w, line)
+		}
+	}
+
+	return err
+}
+
+// advance moves the clock of the Pipeline forward to the datetime of the Event and returns it.
+func (p *Pipeline) advance(ie Event) time.Time {
+	if at := ie.time(); at.After(p.now) {
+		p.now = at
+	}
+
+	return p.now
+}
+
+// shard returns the index of the worker that owns the given Account ID.
+func shard(id string, workers int) int {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+
+	return int(h.Sum32() % uint32(workers))
+}
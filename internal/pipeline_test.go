@@ -0,0 +1,188 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPipeline_Run(t *testing.T) {
+	in := lineStream(32, 2000)
+	want := sequential(in)
+
+	for _, workers := range []int{0, 1, 2, 8, 64} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			var out bytes.Buffer
+			if err := NewPipeline(workers, NewTimeline).Run(strings.NewReader(in), &out); err != nil {
+				t.Fatalf("want no error, got: %v", err)
+			}
+			if got := out.String(); got != want {
+				t.Errorf("workers=%d, output differs from the sequential one", workers)
+			}
+		})
+	}
+}
+
+func TestPipeline_Run_LateReorder(t *testing.T) {
+	in := `{"account":{"account-id":"bob","active-card":true,"available-limit":100}}
+{"account":{"account-id":"alice","active-card":true,"available-limit":100}}
+{"transaction":{"account-id":"bob","merchant":"Ottawa Senators","amount":10,"time":"2019-02-13T11:01:00.000Z"}}
+{"transaction":{"account-id":"alice","merchant":"Winnipeg Jets","amount":20,"time":"2019-02-13T11:00:30.000Z"}}
+{"transaction":{"account-id":"bob","merchant":"Calgary Flames","amount":30,"time":"2019-02-13T11:00:00.000Z"}}
+{"transaction":`
+	want := `{"Account":{"account-id":"bob","active-card":true,"available-limit":100},"violations":[]}
+{"Account":{"account-id":"alice","active-card":true,"available-limit":100},"violations":[]}
+{"line":6,"error":"malformed-json","detail":"unexpected end of JSON input"}
+{"Account":{"account-id":"alice","active-card":true,"available-limit":80},"violations":[]}
+{"Account":{"account-id":"bob","active-card":true,"available-limit":70},"violations":[]}
+{"Account":{"account-id":"bob","active-card":true,"available-limit":60},"violations":[]}
+`
+	cfg := DefaultConfig()
+	cfg.LatePolicy, cfg.Lateness = LateReorder, duration(5*time.Minute)
+
+	for _, workers := range []int{1, 2, 8} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			var out bytes.Buffer
+			pipeline := NewPipeline(workers, func() Timeline { return NewTimelineWithConfig(cfg) })
+			if err := pipeline.Run(strings.NewReader(in), &out); err != nil {
+				t.Fatalf("want no error, got: %v", err)
+			}
+			if got := out.String(); got != want {
+				t.Errorf("workers=%d, want:\n%s\ngot:\n%s", workers, want, got)
+			}
+		})
+	}
+}
+
+func TestPipeline_Run_HoldExpiry(t *testing.T) {
+	in := `{"account":{"account-id":"alice","active-card":true,"available-limit":100}}
+{"account":{"account-id":"bob","active-card":true,"available-limit":100}}
+{"authorization":{"id":"hotel","account-id":"alice","merchant":"Calgary Flames","amount":70,"time":"2019-02-13T11:00:00.000Z"}}
+{"transaction":{"account-id":"bob","merchant":"Edmonton Oilers","amount":10,"time":"2019-02-21T11:00:00.000Z"}}
+{"capture":{"account-id":"alice","authorization-id":"hotel","amount":70,"time":"2019-02-14T11:00:00.000Z"}}
+`
+	want := `{"Account":{"account-id":"alice","active-card":true,"available-limit":100},"violations":[]}
+{"Account":{"account-id":"bob","active-card":true,"available-limit":100},"violations":[]}
+{"Account":{"account-id":"alice","active-card":true,"available-limit":30,"held-limit":70},"violations":[]}
+{"Account":{"account-id":"bob","active-card":true,"available-limit":90},"violations":[]}
+{"Account":{"account-id":"alice","active-card":true,"available-limit":100},"violations":["unknown-authorization"]}
+`
+
+	for _, workers := range []int{1, 2, 8} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			var out bytes.Buffer
+			if err := NewPipeline(workers, NewTimeline).Run(strings.NewReader(in), &out); err != nil {
+				t.Fatalf("want no error, got: %v", err)
+			}
+			if got := out.String(); got != want {
+				t.Errorf("workers=%d, want:\n%s\ngot:\n%s", workers, want, got)
+			}
+		})
+	}
+}
+
+func TestPipeline_Run_Empty(t *testing.T) {
+	var out bytes.Buffer
+	if err := NewPipeline(4, NewTimeline).Run(strings.NewReader(""), &out); err != nil {
+		t.Fatalf("want no error, got: %v", err)
+	}
+	if got := out.String(); got != "" {
+		t.Errorf("want nothing, got: %q", got)
+	}
+}
+
+func TestPipeline_Run_LineTooLong(t *testing.T) {
+	in := `{"account":{"active-card":true,"available-limit":100}}
+` + strings.Repeat(" ", maxBodySize+1) + `
+{"transaction":{"merchant":"Detroit Red Wings","amount":20,"time":"2019-02-13T11:00:00.000Z"}}
+` + strings.Repeat(" ", maxBodySize+1)
+	want := `{"Account":{"active-card":true,"available-limit":100},"violations":[]}
+{"line":2,"error":"line-too-long"}
+{"Account":{"active-card":true,"available-limit":80},"violations":[]}
+{"line":4,"error":"line-too-long"}
+`
+
+	var out bytes.Buffer
+	if err := NewPipeline(4, NewTimeline).Run(strings.NewReader(in), &out); err != nil {
+		t.Fatalf("want no error, got: %v", err)
+	}
+	if got := out.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestPipeline_Run_WriteError(t *testing.T) {
+	err := NewPipeline(4, NewTimeline).Run(strings.NewReader(lineStream(4, 100)), failingWriter{})
+	if err != io.ErrShortWrite {
+		t.Errorf("want: %v, got: %v", io.ErrShortWrite, err)
+	}
+}
+
+// BenchmarkPipeline_Run processes a stream of 64 accounts with an increasing number of workers.
+// events/s must grow with the number of workers up to the number of CPUs.
+func BenchmarkPipeline_Run(b *testing.B) {
+	const accounts, n = 64, 100000
+	in := lineStream(accounts, n)
+	for _, workers := range []int{1, 2, 4, 8, 16} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			start := time.Now()
+			for i := 0; i < b.N; i++ {
+				if err := NewPipeline(workers, NewTimeline).Run(strings.NewReader(in), io.Discard); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.N*n)/time.Since(start).Seconds(), "events/s")
+		})
+	}
+}
+
+// lineStream returns n input lines interleaving the given number of accounts. Each account is initialized and then
+// receives Transaction 20 seconds apart, so some of them are rejected by the velocity rules. Every 100th line is
+// malformed.
+func lineStream(accounts, n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("account-%d", i%accounts)
+		switch {
+		case i%100 == 99:
+			sb.WriteString(`{"transaction":`)
+		case i < accounts:
+			fmt.Fprintf(&sb, `{"account":{"account-id":%q,"active-card":true,"available-limit":%d}}`, id, n)
+		default:
+			at := time.Time(trTime).Add(time.Duration(i/accounts) * 20 * time.Second)
+			fmt.Fprintf(&sb, `{"transaction":{"account-id":%q,"merchant":"merchant-%d","amount":1,"time":%q}}`,
+				id, i%10, at.Format(time.RFC3339Nano))
+		}
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
+
+// sequential returns the output of the input lines processed one at a time by a single Authorizer.
+func sequential(in string) string {
+	var sb strings.Builder
+	authorizer := NewAuthorizer(NewTimeline)
+	for i, input := range strings.Split(strings.TrimSuffix(in, "\n"), "\n") {
+		event, err := Parse(input)
+		if err != nil {
+			sb.WriteString(Rejection{Line: i + 1, Err: err}.String())
+		} else {
+			authorizer.Process(event)
+			sb.WriteString(authorizer.Last().String())
+		}
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
+
+// failingWriter is an io.Writer that always fails.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, io.ErrShortWrite
+}
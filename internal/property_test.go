@@ -0,0 +1,152 @@
+package internal
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// stream is a generated sequence of Event: an optional initialization followed by Transaction in time order.
+// Transaction are close enough in time and merchants to violate the velocity rules often.
+type stream []Event
+
+// Generate implements quick.Generator interface.
+func (stream) Generate(r *rand.Rand, size int) reflect.Value {
+	merchants := []string{"Boston Bruins", "Chicago Blackhawks", "Dallas Stars", "Vegas Golden Knights"}
+	s := make(stream, 0, size+1)
+	if r.Intn(10) > 0 {
+		s = append(s, Event{Account: &Account{ActiveCard: r.Intn(10) > 0, AvailableLimit: r.Intn(1000)}})
+	}
+
+	at := time.Time(trTime)
+	for i := 0; i < size; i++ {
+		at = at.Add(time.Duration(r.Intn(90)) * time.Second)
+		s = append(s, Event{Transaction: &Transaction{
+			Merchant: merchants[r.Intn(len(merchants))],
+			Amount:   r.Intn(200),
+			Time:     datetime(at),
+		}})
+	}
+
+	return reflect.ValueOf(s)
+}
+
+// processed is the result of a stream processed by a Timeline.
+type processed struct {
+	// initial is the Account state after the initialization. It is nil when the stream has none.
+	initial *Account
+	// final is the Account state after the stream.
+	final *Account
+	// approved are the valid Transaction, in time order.
+	approved []Transaction
+	// unchanged is false when a rejected Transaction changed the Account state.
+	unchanged bool
+}
+
+// process processes the stream by a Timeline with DefaultConfig.
+func (s stream) process() processed {
+	timeline := NewTimeline()
+	p := processed{unchanged: true}
+	for _, ie := range s {
+		before := timeline.State()
+		timeline.Process(ie)
+		te := timeline.Last()
+		switch {
+		case ie.Transaction == nil:
+			p.initial = timeline.State()
+		case te.hasViolation():
+			p.unchanged = p.unchanged && reflect.DeepEqual(before, timeline.State())
+		default:
+			p.approved = append(p.approved, *ie.Transaction)
+		}
+	}
+	p.final = timeline.State()
+
+	return p
+}
+
+// within returns the approved Transaction within the window of the DefaultConfig before tr, including itself.
+func (p processed) within(tr Transaction) []Transaction {
+	ws := make([]Transaction, 0)
+	for _, a := range p.approved {
+		if !time.Time(a.Time).Before(since(tr, time.Duration(DefaultConfig().Window))) && !time.Time(a.Time).After(time.Time(tr.Time)) {
+			ws = append(ws, a)
+		}
+	}
+
+	return ws
+}
+
+func TestTimeline_Process_Properties(t *testing.T) {
+	cases := []struct {
+		name     string
+		property func(processed) bool
+	}{
+		{"approved amounts are the used limit", func(p processed) bool {
+			if p.initial == nil {
+				return len(p.approved) == 0 && p.final == nil
+			}
+			sum := 0
+			for _, a := range p.approved {
+				sum += a.Amount
+			}
+			return sum == p.initial.AvailableLimit-p.final.AvailableLimit
+		}},
+		{"at most max transactions within window", func(p processed) bool {
+			for _, a := range p.approved {
+				if len(p.within(a)) > DefaultConfig().MaxTransactions {
+					return false
+				}
+			}
+			return true
+		}},
+		{"one transaction of each merchant within window", func(p processed) bool {
+			for _, a := range p.approved {
+				same := 0
+				for _, w := range p.within(a) {
+					if w.Merchant == a.Merchant {
+						same++
+					}
+				}
+				if same > 1 {
+					return false
+				}
+			}
+			return true
+		}},
+		{"rejected transactions do not change state", func(p processed) bool {
+			return p.unchanged
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			property := func(s stream) bool { return c.property(s.process()) }
+			if err := quick.Check(property, &quick.Config{MaxCount: 500}); err != nil {
+				t.Error(describe(err))
+			}
+		})
+	}
+}
+
+// describe formats a quick.CheckError with the Event of the failing stream, one per line.
+func describe(err error) string {
+	ce, ok := err.(*quick.CheckError)
+	if !ok {
+		return err.Error()
+	}
+	msg := fmt.Sprintf("#%d: failed on stream:", ce.Count)
+	for _, ie := range ce.In[0].(stream) {
+		if ie.Transaction != nil {
+			tr := ie.Transaction
+			msg += fmt.Sprintf("\ntransaction %s %d at %s", tr.Merchant, tr.Amount, time.Time(tr.Time).Format(time.RFC3339))
+		} else {
+			msg += fmt.Sprintf("\naccount %+v", *ie.Account)
+		}
+	}
+
+	return msg
+}
@@ -0,0 +1,282 @@
+package internal
+
+import "time"
+
+type (
+	// View is a read-only view of the Timeline given to each Rule.
+	View interface {
+		// State returns the current Account state. It returns nil when the Account is not initialized.
+		State() *Account
+		// Window returns the valid Transaction that happened at or after since and at or before until, oldest first.
+		// Only the Transaction within the largest WindowedRule window are kept, so the result is partial when since
+		// is older than it. The returned slice must not be modified.
+		Window(since, until time.Time) []Transaction
+		// MerchantWindow is the same as Window, but it only returns the Transaction of the given Merchant.
+		MerchantWindow(merchant string, since, until time.Time) []Transaction
+	}
+	// Rule validates a candidate Transaction before it is put into the Timeline.
+	Rule interface {
+		// Validate returns all violations of the Transaction according this Rule.
+		// acc is the current Account state, and it is nil when the Account is not initialized.
+		// It returns an empty (or nil) slice when the Transaction complies with the Rule.
+		Validate(v View, acc *Account, tr Transaction) []Violation
+	}
+	// WindowedRule is a Rule that looks at the valid Transaction within a window of time.
+	// The Timeline keeps the Transaction within the largest window of its rules, see View.
+	WindowedRule interface {
+		Rule
+		// Window returns how long before the candidate Transaction the Rule looks at.
+		Window() time.Duration
+	}
+	// Explainer is implemented by the Rule that could explain their violations, see Config.Explain.
+	Explainer interface {
+		// Explain returns the evidence of a violation returned by Validate with the same arguments.
+		Explain(v View, acc *Account, tr Transaction, violation Violation) Explanation
+	}
+	// Explanation is the evidence of a violation, so it could be audited without evaluating the rules again.
+	// Only the properties used by the Rule are present.
+	Explanation struct {
+		// Violation is the explained violation.
+		Violation Violation `json:"violation"`
+		// Parameters has the thresholds of the Rule, e.g. the max number of Transaction within an interval.
+		Parameters map[string]interface{} `json:"parameters,omitempty"`
+		// Transactions are the prior valid Transaction counted by the Rule, oldest first.
+		Transactions []Transaction `json:"transactions,omitempty"`
+		// Amount is the amount compared against the Account available limit.
+		Amount *int `json:"amount,omitempty"`
+		// Account is the Account state used by the Rule.
+		Account *Account `json:"account,omitempty"`
+	}
+	// RuleFunc is an adapter to allow the use of ordinary functions as Rule.
+	RuleFunc func(v View, acc *Account, tr Transaction) []Violation
+	// Rules is the registry of Rule that Timeline iterates to validate a Transaction.
+	// Rules are evaluated in the same order they were registered.
+	Rules []Rule
+
+	// AccountInitializedRule is violated when there is no Account initialized.
+	AccountInitializedRule struct{}
+	// ActiveCardRule is violated when the card of the Account is not active.
+	ActiveCardRule struct{}
+	// LimitRule is violated when the Transaction amount exceeds the available limit.
+	LimitRule struct{}
+	// HighFrequencyRule is violated when there are Max or more valid Transaction within Interval.
+	HighFrequencyRule struct {
+		// Max is the number of valid Transaction allowed within Interval.
+		Max int
+		// Interval is the window of time before the Transaction that is taken into account. Transaction after it are
+		// not taken into account, even when they were processed before it, unless the late policy is LateReject.
+		Interval time.Duration
+	}
+	// DoubleTransactionRule is violated when there are Max or more valid Transaction
+	// of the same Merchant within Interval.
+	DoubleTransactionRule struct {
+		// Max is the number of valid Transaction of the same Merchant allowed within Interval.
+		Max int
+		// Interval is the window of time before the Transaction that is taken into account. Transaction after it are
+		// not taken into account, even when they were processed before it, unless the late policy is LateReject.
+		Interval time.Duration
+	}
+
+	// guard wraps a Rule whose violations stop the evaluation of the next rules.
+	guard struct {
+		Rule
+	}
+)
+
+// DefaultRules returns the Rules described in README.md with the DefaultConfig thresholds.
+// AccountInitializedRule and ActiveCardRule are guards, so when they are violated no other Rule is evaluated.
+func DefaultRules() Rules {
+	return DefaultConfig().Rules()
+}
+
+// NewRules creates Rules with the given Rule in the given order.
+func NewRules(rules ...Rule) Rules {
+	rs := make(Rules, 0, len(rules))
+	for _, r := range rules {
+		rs.Register(r)
+	}
+
+	return rs
+}
+
+// Guard wraps a Rule, so when it is violated the evaluation of the next rules is stopped.
+// Rules registered after guards could rely on the conditions checked by them, e.g. a non nil Account.
+func Guard(r Rule) Rule {
+	return guard{Rule: r}
+}
+
+// Register appends a Rule at the end of Rules.
+func (rs *Rules) Register(r Rule) {
+	*rs = append(*rs, r)
+}
+
+// Validate evaluates all Rules and returns their violations.
+// The returned slice is never nil.
+func (rs Rules) Validate(v View, acc *Account, tr Transaction) []Violation {
+	violations := make([]Violation, 0)
+	for _, r := range rs {
+		vs := r.Validate(v, acc, tr)
+		violations = append(violations, vs...)
+		if _, ok := r.(guard); ok && len(vs) > 0 {
+			break
+		}
+	}
+
+	return violations
+}
+
+// Explain evaluates all Rules, as Validate does, and returns the Explanation of their violations.
+// Violations of Rule that are not Explainer only have the Account state used.
+// The returned slice is never nil.
+func (rs Rules) Explain(v View, acc *Account, tr Transaction) []Explanation {
+	explanations := make([]Explanation, 0)
+	for _, r := range rs {
+		vs := r.Validate(v, acc, tr)
+		e, ok := r.(Explainer)
+		if g, guarded := r.(guard); guarded {
+			e, ok = g.Rule.(Explainer)
+		}
+		for _, vi := range vs {
+			if ok {
+				explanations = append(explanations, e.Explain(v, acc, tr, vi))
+			} else {
+				explanations = append(explanations, Explanation{Violation: vi, Account: acc})
+			}
+		}
+		if _, ok := r.(guard); ok && len(vs) > 0 {
+			break
+		}
+	}
+
+	return explanations
+}
+
+// Window returns the largest window of all WindowedRule, including the guarded ones.
+func (rs Rules) Window() (max time.Duration) {
+	for _, r := range rs {
+		if g, ok := r.(guard); ok {
+			r = g.Rule
+		}
+		if wr, ok := r.(WindowedRule); ok && wr.Window() > max {
+			max = wr.Window()
+		}
+	}
+
+	return
+}
+
+// Validate calls f(v, acc, tr).
+func (f RuleFunc) Validate(v View, acc *Account, tr Transaction) []Violation {
+	return f(v, acc, tr)
+}
+
+// Validate implements Rule interface.
+func (AccountInitializedRule) Validate(_ View, acc *Account, _ Transaction) []Violation {
+	if acc == nil {
+		return []Violation{accountNotInitialized}
+	}
+
+	return nil
+}
+
+// Explain implements Explainer interface.
+func (AccountInitializedRule) Explain(_ View, _ *Account, _ Transaction, violation Violation) Explanation {
+	return Explanation{Violation: violation}
+}
+
+// Validate implements Rule interface.
+func (ActiveCardRule) Validate(_ View, acc *Account, _ Transaction) []Violation {
+	if acc == nil || !acc.ActiveCard {
+		return []Violation{cardNotActive}
+	}
+
+	return nil
+}
+
+// Explain implements Explainer interface.
+func (ActiveCardRule) Explain(_ View, acc *Account, _ Transaction, violation Violation) Explanation {
+	return Explanation{Violation: violation, Account: acc}
+}
+
+// Validate implements Rule interface.
+func (LimitRule) Validate(_ View, acc *Account, tr Transaction) []Violation {
+	if acc == nil || tr.Amount > acc.AvailableLimit {
+		return []Violation{insufficientLimit}
+	}
+
+	return nil
+}
+
+// Explain implements Explainer interface.
+func (LimitRule) Explain(_ View, acc *Account, tr Transaction, violation Violation) Explanation {
+	return Explanation{Violation: violation, Amount: &tr.Amount, Account: acc}
+}
+
+// Validate implements Rule interface.
+func (r HighFrequencyRule) Validate(v View, _ *Account, tr Transaction) []Violation {
+	if len(v.Window(since(tr, r.Interval), time.Time(tr.Time))) >= r.Max {
+		return []Violation{highFrequency}
+	}
+
+	return nil
+}
+
+// Explain implements Explainer interface.
+func (r HighFrequencyRule) Explain(v View, _ *Account, tr Transaction, violation Violation) Explanation {
+	return Explanation{
+		Violation:    violation,
+		Parameters:   map[string]interface{}{"max": r.Max, "interval": duration(r.Interval)},
+		Transactions: append([]Transaction{}, v.Window(since(tr, r.Interval), time.Time(tr.Time))...),
+	}
+}
+
+// Window implements WindowedRule interface.
+func (r HighFrequencyRule) Window() time.Duration {
+	return r.Interval
+}
+
+// Validate implements Rule interface.
+func (r DoubleTransactionRule) Validate(v View, _ *Account, tr Transaction) []Violation {
+	if len(v.MerchantWindow(tr.Merchant, since(tr, r.Interval), time.Time(tr.Time))) >= r.Max {
+		return []Violation{doubleTransaction}
+	}
+
+	return nil
+}
+
+// Explain implements Explainer interface.
+func (r DoubleTransactionRule) Explain(v View, _ *Account, tr Transaction, violation Violation) Explanation {
+	return Explanation{
+		Violation:    violation,
+		Parameters:   map[string]interface{}{"max": r.Max, "interval": duration(r.Interval)},
+		Transactions: append([]Transaction{}, v.MerchantWindow(tr.Merchant, since(tr, r.Interval), time.Time(tr.Time))...),
+	}
+}
+
+// Window implements WindowedRule interface.
+func (r DoubleTransactionRule) Window() time.Duration {
+	return r.Interval
+}
+
+// since returns the datetime interval before the Transaction.
+func since(tr Transaction, interval time.Duration) time.Time {
+	return time.Time(tr.Time).Add(-interval)
+}
+
+// clone returns a deep copy of the Explanation. Parameters are shared, since they are never modified.
+func (e Explanation) clone() Explanation {
+	c := e
+	if e.Transactions != nil {
+		c.Transactions = append(make([]Transaction, 0, len(e.Transactions)), e.Transactions...)
+	}
+	if e.Amount != nil {
+		amount := *e.Amount
+		c.Amount = &amount
+	}
+	if e.Account != nil {
+		acc := *e.Account
+		c.Account = &acc
+	}
+
+	return c
+}
@@ -0,0 +1,148 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRules_Validate(t *testing.T) {
+	const maxAmount = Violation("max-amount")
+	maxAmountRule := RuleFunc(func(_ View, _ *Account, tr Transaction) []Violation {
+		if tr.Amount > 50 {
+			return []Violation{maxAmount}
+		}
+		return nil
+	})
+	active := &Account{ActiveCard: true, AvailableLimit: 100}
+	inactive := &Account{ActiveCard: false, AvailableLimit: 100}
+	tr := Transaction{Merchant: "Seattle Kraken", Amount: 60, Time: trTime}
+
+	cases := []struct {
+		name  string
+		rules Rules
+		acc   *Account
+		want  []Violation
+	}{
+		{"without rules", NewRules(), active, []Violation{}},
+		{"default rules", DefaultRules(), active, []Violation{}},
+		{"custom rule", NewRules(maxAmountRule), active, []Violation{maxAmount}},
+		{"guard stops evaluation", NewRules(Guard(ActiveCardRule{}), maxAmountRule), inactive, []Violation{cardNotActive}},
+		{"non guard does not stop evaluation", NewRules(ActiveCardRule{}, maxAmountRule), inactive, []Violation{cardNotActive, maxAmount}},
+		{"guard not violated", NewRules(Guard(ActiveCardRule{}), maxAmountRule), active, []Violation{maxAmount}},
+		{"account not initialized", DefaultRules(), nil, []Violation{accountNotInitialized}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.rules.Validate(NewTimeline(), c.acc, tr); !reflect.DeepEqual(c.want, got) {
+				t.Errorf("%s, want: %v, got: %v", c.name, c.want, got)
+			}
+		})
+	}
+}
+
+func TestRules_Explain(t *testing.T) {
+	const maxAmount = Violation("max-amount")
+	maxAmountRule := RuleFunc(func(_ View, _ *Account, tr Transaction) []Violation {
+		if tr.Amount > 50 {
+			return []Violation{maxAmount}
+		}
+		return nil
+	})
+	active := &Account{ActiveCard: true, AvailableLimit: 100}
+	inactive := &Account{ActiveCard: false, AvailableLimit: 100}
+	prior := Transaction{Merchant: "Seattle Kraken", Amount: 10, Time: trTime}
+	tr := Transaction{Merchant: "Seattle Kraken", Amount: 60, Time: datetime(time.Time(trTime).Add(time.Minute))}
+	amount := tr.Amount
+
+	v := NewTimeline()
+	v.Process(Event{Account: &Account{ActiveCard: true, AvailableLimit: 100}})
+	v.Process(Event{Transaction: &prior})
+
+	cases := []struct {
+		name  string
+		rules Rules
+		acc   *Account
+		want  []Explanation
+	}{
+		{"without violations", NewRules(LimitRule{}), active, []Explanation{}},
+		{"account not initialized", NewRules(Guard(AccountInitializedRule{}), LimitRule{}), nil,
+			[]Explanation{{Violation: accountNotInitialized}}},
+		{"guarded card not active", NewRules(Guard(ActiveCardRule{}), maxAmountRule), inactive,
+			[]Explanation{{Violation: cardNotActive, Account: inactive}}},
+		{"insufficient limit", NewRules(LimitRule{}), &Account{ActiveCard: true, AvailableLimit: 50},
+			[]Explanation{{Violation: insufficientLimit, Amount: &amount, Account: &Account{ActiveCard: true, AvailableLimit: 50}}}},
+		{"high frequency", NewRules(HighFrequencyRule{Max: 1, Interval: 2 * time.Minute}), active,
+			[]Explanation{{
+				Violation:    highFrequency,
+				Parameters:   map[string]interface{}{"max": 1, "interval": duration(2 * time.Minute)},
+				Transactions: []Transaction{prior},
+			}}},
+		{"double transaction", NewRules(DoubleTransactionRule{Max: 1, Interval: 2 * time.Minute}), active,
+			[]Explanation{{
+				Violation:    doubleTransaction,
+				Parameters:   map[string]interface{}{"max": 1, "interval": duration(2 * time.Minute)},
+				Transactions: []Transaction{prior},
+			}}},
+		{"custom rule", NewRules(maxAmountRule), active, []Explanation{{Violation: maxAmount, Account: active}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.rules.Explain(v, c.acc, tr); !reflect.DeepEqual(c.want, got) {
+				t.Errorf("%s, want: %+v, got: %+v", c.name, c.want, got)
+			}
+		})
+	}
+}
+
+func TestRules_Window(t *testing.T) {
+	cases := []struct {
+		name  string
+		rules Rules
+		want  time.Duration
+	}{
+		{"without rules", NewRules(), 0},
+		{"without windowed rules", NewRules(LimitRule{}), 0},
+		{"default rules", DefaultRules(), 2 * time.Minute},
+		{"largest window", NewRules(HighFrequencyRule{Interval: time.Minute}, DoubleTransactionRule{Interval: time.Hour}), time.Hour},
+		{"guarded windowed rule", NewRules(Guard(HighFrequencyRule{Interval: time.Hour})), time.Hour},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.rules.Window(); got != c.want {
+				t.Errorf("%s, want: %v, got: %v", c.name, c.want, got)
+			}
+		})
+	}
+}
+
+func TestNewTimelineWithRules(t *testing.T) {
+	const weekend = Violation("weekend")
+	weekendRule := RuleFunc(func(_ View, _ *Account, tr Transaction) []Violation {
+		if wd := time.Time(tr.Time).Weekday(); wd == time.Saturday || wd == time.Sunday {
+			return []Violation{weekend}
+		}
+		return nil
+	})
+	rules := DefaultRules()
+	rules.Register(weekendRule)
+	saturday := datetime(time.Date(2019, time.February, 16, 11, 0, 0, 0, time.UTC))
+
+	timeline := NewTimelineWithRules(rules)
+	timeline.Process(Event{Account: &Account{ActiveCard: true, AvailableLimit: 100}})
+	timeline.Process(Event{Transaction: &Transaction{Merchant: "Anaheim Ducks", Amount: 10, Time: trTime}})
+	timeline.Process(Event{Transaction: &Transaction{Merchant: "Dallas Stars", Amount: 10, Time: saturday}})
+
+	want := [][]Violation{{}, {}, {weekend}}
+	for i, te := range timeline.Events() {
+		if !reflect.DeepEqual(want[i], te.Violations) {
+			t.Errorf("event %d, want: %v, got: %v", i, want[i], te.Violations)
+		}
+	}
+	if got := timeline.State().AvailableLimit; got != 90 {
+		t.Errorf("want: %d, got: %d", 90, got)
+	}
+}
@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxBodySize is the largest request body accepted by Server.
+const maxBodySize = 1 << 20
+
+var (
+	errAccountNotFound  = errors.New("account-not-found")
+	errNotFound         = errors.New("not-found")
+	errMethodNotAllowed = errors.New("method-not-allowed")
+	errBodyTooLarge     = errors.New("body-too-large")
+)
+
+type (
+	// Server exposes a ConcurrentAuthorizer through an HTTP API:
+	//  POST /accounts       processes the Account of the body, as an "account" input event;
+	//  POST /transactions   processes the Transaction of the body, as a "transaction" input event;
+	//  GET  /accounts/{id}  returns the current state of the Account.
+	// Processed Events are answered with 200 OK and the TimelineEvent output, even when it has violations.
+	// Bodies that are not valid Events are answered with 400 Bad Request and the Rejection output.
+	Server struct {
+		// authorizer processes the Events of all requests.
+		authorizer *ConcurrentAuthorizer
+		// mux routes the requests to their handlers.
+		mux *http.ServeMux
+	}
+)
+
+// NewServer creates a new Server backed by the given ConcurrentAuthorizer.
+func NewServer(authorizer *ConcurrentAuthorizer) *Server {
+	s := &Server{authorizer: authorizer, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/accounts", s.post("account"))
+	s.mux.HandleFunc("/transactions", s.post("transaction"))
+	s.mux.HandleFunc("/accounts/", s.account)
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		reject(w, http.StatusNotFound, errNotFound)
+	})
+
+	return s
+}
+
+// ServeHTTP implements http.Handler interface.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Serve serves the requests of the listener until ctx is done. Then it shuts down gracefully: it stops accepting
+// connections and waits up to drain for the in-flight requests to be answered.
+// It returns nil when all in-flight requests were answered.
+func (s *Server) Serve(ctx context.Context, l net.Listener, drain time.Duration) error {
+	srv := &http.Server{Handler: s}
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(l)
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdown, cancel := context.WithTimeout(context.Background(), drain)
+	defer cancel()
+	if err := srv.Shutdown(shutdown); err != nil {
+		return err
+	}
+	if err := <-served; err != http.ErrServerClosed {
+		return err
+	}
+
+	return nil
+}
+
+// post returns a handler that processes the body as an input event of the given type.
+func (s *Server) post(eventType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			reject(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			reject(w, http.StatusRequestEntityTooLarge, errBodyTooLarge)
+			return
+		}
+		ie, err := ParseType(eventType, body)
+		if err != nil {
+			reject(w, http.StatusBadRequest, err)
+			return
+		}
+
+		respond(w, http.StatusOK, s.authorizer.Process(ie).String())
+	}
+}
+
+// account handles GET /accounts/{id}.
+func (s *Server) account(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		reject(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/accounts/")
+	acc, ok := s.authorizer.State(id)
+	if id == "" || strings.Contains(id, "/") || !ok {
+		reject(w, http.StatusNotFound, errAccountNotFound)
+		return
+	}
+
+	respond(w, http.StatusOK, TimelineEvent{Event: Event{Account: &acc}, Violations: make([]Violation, 0)}.String())
+}
+
+// reject writes the error as a Rejection output with the given status code.
+func reject(w http.ResponseWriter, code int, err error) {
+	respond(w, code, Rejection{Err: err}.String())
+}
+
+// respond writes the JSON output line with the given status code.
+func respond(w http.ResponseWriter, code int, output string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	io.WriteString(w, output+"\n")
+}
@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestServer_ServeHTTP(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		code   int
+		want   string
+	}{
+		{"account not found", http.MethodGet, "/accounts/alice", "", http.StatusNotFound, `{"error":"account-not-found"}`},
+		{"transaction before account", http.MethodPost, "/transactions", `{"account-id":"alice","merchant":"Boston Bruins","amount":20,"time":"2019-02-13T11:00:00.000Z"}`,
+			http.StatusOK, `{"Account":{"account-id":"alice"},"violations":["Account-not-initialized"]}`},
+		{"account", http.MethodPost, "/accounts", `{"account-id":"alice","active-card":true,"available-limit":100}`,
+			http.StatusOK, `{"Account":{"account-id":"alice","active-card":true,"available-limit":100},"violations":[]}`},
+		{"account again", http.MethodPost, "/accounts", `{"account-id":"alice","active-card":true,"available-limit":350}`,
+			http.StatusOK, `{"Account":{"account-id":"alice","active-card":true,"available-limit":100},"violations":["Account-already-initialized"]}`},
+		{"transaction", http.MethodPost, "/transactions", `{"account-id":"alice","merchant":"Boston Bruins","amount":20,"time":"2019-02-13T11:00:00.000Z"}`,
+			http.StatusOK, `{"Account":{"account-id":"alice","active-card":true,"available-limit":80},"violations":[]}`},
+		{"double transaction", http.MethodPost, "/transactions", `{"account-id":"alice","merchant":"Boston Bruins","amount":20,"time":"2019-02-13T11:00:30.000Z"}`,
+			http.StatusOK, `{"Account":{"account-id":"alice","active-card":true,"available-limit":80},"violations":["double-Transaction"]}`},
+		{"account state", http.MethodGet, "/accounts/alice", "", http.StatusOK, `{"Account":{"account-id":"alice","active-card":true,"available-limit":80},"violations":[]}`},
+		{"malformed body", http.MethodPost, "/transactions", `{"account-id":`, http.StatusBadRequest, `{"error":"malformed-json","detail":"unexpected end of JSON input"}`},
+		{"empty body", http.MethodPost, "/accounts", `null`, http.StatusBadRequest, `{"error":"empty-event","detail":"event has no event type"}`},
+		{"invalid time", http.MethodPost, "/transactions", `{"merchant":"Boston Bruins","amount":20,"time":"yesterday"}`,
+			http.StatusBadRequest, `{"error":"invalid-time","detail":"parsing time \"yesterday\" as \"2006-01-02T15:04:05Z07:00\": cannot parse \"yesterday\" as \"2006\""}`},
+		{"negative amount", http.MethodPost, "/transactions", `{"merchant":"Boston Bruins","amount":-20,"time":"2019-02-13T11:00:00.000Z"}`,
+			http.StatusBadRequest, `{"error":"negative-amount","detail":"amount must not be negative"}`},
+		{"body too large", http.MethodPost, "/accounts", strings.Repeat(" ", maxBodySize+1), http.StatusRequestEntityTooLarge, `{"error":"body-too-large"}`},
+		{"get transactions", http.MethodGet, "/transactions", "", http.StatusMethodNotAllowed, `{"error":"method-not-allowed"}`},
+		{"post account state", http.MethodPost, "/accounts/alice", "", http.StatusMethodNotAllowed, `{"error":"method-not-allowed"}`},
+		{"nested path", http.MethodGet, "/accounts/alice/transactions", "", http.StatusNotFound, `{"error":"account-not-found"}`},
+		{"unknown path", http.MethodGet, "/cards", "", http.StatusNotFound, `{"error":"not-found"}`},
+	}
+
+	server := NewServer(newConcurrentAuthorizer(t))
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, strings.NewReader(c.body)))
+
+			if rec.Code != c.code {
+				t.Errorf("%s, want: %d, got: %d", c.name, c.code, rec.Code)
+			}
+			if got := rec.Body.String(); got != c.want+"\n" {
+				t.Errorf("%s, want: %s, got: %s", c.name, c.want, got)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("%s, want: application/json, got: %s", c.name, got)
+			}
+		})
+	}
+}
+
+// TestServer_Serve shuts the Server down while a request is in-flight and checks that it is answered before Serve
+// returns, while new connections are refused.
+func TestServer_Serve(t *testing.T) {
+	authorizer := newConcurrentAuthorizer(t)
+	authorizer.Process(Event{Account: &Account{ID: "alice", ActiveCard: true, AvailableLimit: 100}})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := "http://" + l.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- NewServer(authorizer).Serve(ctx, l, 5*time.Second)
+	}()
+
+	// Holding the lock of the Account keeps the request in-flight.
+	lt, _ := authorizer.lookup("alice")
+	lt.mu.Lock()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		body := `{"account-id":"alice","merchant":"Boston Bruins","amount":20,"time":"2019-02-13T11:00:00.000Z"}`
+		resp, err := http.Post(url+"/transactions", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Errorf("want in-flight request answered, got: %v", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("want: %d, got: %d", http.StatusOK, resp.StatusCode)
+		}
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-served:
+		t.Fatalf("want Serve waiting for in-flight request, got: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	lt.mu.Unlock()
+	wg.Wait()
+
+	if err := <-served; err != nil {
+		t.Errorf("want: nil, got: %v", err)
+	}
+	if got := len(authorizer.Events("alice")); got != 2 {
+		t.Errorf("want: %d events, got: %d", 2, got)
+	}
+	if _, err := http.Get(url + "/accounts/alice"); err == nil {
+		t.Errorf("want connection refused after shutdown")
+	}
+}
@@ -0,0 +1,236 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// SnapshotVersion is the version of the Snapshot file format. It changes whenever the format does.
+const SnapshotVersion = 1
+
+// ErrInvalidSnapshot is returned when a Snapshot file could not be restored.
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+type (
+	// Snapshot is the state of all Timeline needed to resume processing without replaying their history.
+	// It is written as a JSON file, see SaveSnapshot and LoadSnapshot.
+	Snapshot struct {
+		// Version is the SnapshotVersion of the file.
+		Version int `json:"version"`
+		// Config is the Config of the Timeline. A Snapshot is only restored with the same rules and settings.
+		Config Config `json:"config"`
+		// Accounts has the TimelineSnapshot of each Account ID.
+		Accounts map[string]TimelineSnapshot `json:"accounts"`
+		// WALSequence is the sequence number of the first WAL record that is not in the Snapshot. Replaying the WAL
+		// from it applies each Event once, even when the process stopped before the WAL was reset.
+		WALSequence uint64 `json:"wal-sequence,omitempty"`
+	}
+	// TimelineSnapshot is the state of a Timeline. The history is not part of it, see Timeline.Restore.
+	TimelineSnapshot struct {
+		// Account is the current Account state. It is nil when the Account is not initialized.
+		Account *snapshotAccount `json:"account,omitempty"`
+		// Window has the valid Transaction within the rules horizon, oldest first.
+		Window []Transaction `json:"window"`
+		// Approved has the valid Transaction with ID that could be reversed or refunded.
+		Approved map[string]snapshotApproval `json:"approved"`
+		// Holds has the authorizations that were neither captured nor expired.
+		Holds map[string]Transaction `json:"holds"`
+		// Decisions has the decision of each Transaction with ID that is not forgotten yet, so retries stay idempotent.
+		Decisions map[string]snapshotDecision `json:"decisions"`
+		// Now is the latest datetime seen by the Timeline.
+		Now datetime `json:"now"`
+		// Watermark is the datetime before which Event are late with the LateReorder policy.
+		Watermark datetime `json:"watermark"`
+		// Summary counts the TimelineEvent that are no longer retained.
+		Summary Summary `json:"summary"`
+	}
+	// snapshotAccount is an Account with all its properties, including the ones that are not part of the input.
+	snapshotAccount struct {
+		ID             string `json:"account-id,omitempty"`
+		ActiveCard     bool   `json:"active-card"`
+		AvailableLimit int    `json:"available-limit"`
+		HeldLimit      int    `json:"held-limit"`
+	}
+	// snapshotApproval is an approval with exported properties.
+	snapshotApproval struct {
+		Amount   int  `json:"amount"`
+		Refunded int  `json:"refunded"`
+		Reversed bool `json:"reversed"`
+	}
+	// snapshotDecision is the TimelineEvent of a Transaction with ID.
+	snapshotDecision struct {
+		Account     *snapshotAccount `json:"account,omitempty"`
+		Transaction Transaction      `json:"transaction"`
+		Hold        bool             `json:"hold,omitempty"`
+		Violations  []Violation      `json:"violations"`
+		// Explanations are the ones of the Violations, so replays after a restore are explained the same way.
+		Explanations []Explanation `json:"explanations,omitempty"`
+	}
+)
+
+// LoadSnapshot reads a Snapshot file written by SaveSnapshot.
+// It returns an error wrapping ErrInvalidSnapshot when the file has another SnapshotVersion or was written with
+// another Config. Only HistoryTail and Explain could differ, since they do not change the state.
+func LoadSnapshot(path string, cfg Config) (Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Snapshot{}, fmt.Errorf("%w: %s: %v", ErrInvalidSnapshot, path, err)
+	}
+	if s.Version != SnapshotVersion {
+		return Snapshot{}, fmt.Errorf("%w: %s: version %d, want %d", ErrInvalidSnapshot, path, s.Version, SnapshotVersion)
+	}
+	cfg.HistoryTail, cfg.Explain = s.Config.HistoryTail, s.Config.Explain
+	if cfg != s.Config {
+		return Snapshot{}, fmt.Errorf("%w: %s: config %s, want %s", ErrInvalidSnapshot, path, s.Config, cfg)
+	}
+
+	return s, nil
+}
+
+// SaveSnapshot writes the Snapshot to the given path. The file is replaced atomically, so a crash never leaves a
+// partial Snapshot behind.
+func SaveSnapshot(path string, s Snapshot) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := writeSnapshot(tmp, s); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// writeSnapshot encodes the Snapshot as JSON with the current SnapshotVersion.
+func writeSnapshot(w io.Writer, s Snapshot) error {
+	s.Version = SnapshotVersion
+
+	return json.NewEncoder(w).Encode(s)
+}
+
+// Snapshot returns the state of the Timeline.
+func (t Timeline) Snapshot() TimelineSnapshot {
+	s := TimelineSnapshot{
+		Account:   newSnapshotAccount(t.current),
+		Window:    append([]Transaction{}, t.recent.since(time.Time{})...),
+		Approved:  make(map[string]snapshotApproval, len(t.approved)),
+		Holds:     make(map[string]Transaction, len(t.holds)),
+		Decisions: make(map[string]snapshotDecision, len(t.decisions)),
+		Now:       datetime(t.now),
+		Watermark: datetime(t.watermark),
+		Summary:   t.summary,
+	}
+	for id, a := range t.approved {
+		s.Approved[id] = snapshotApproval{Amount: a.amount, Refunded: a.refunded, Reversed: a.reversed}
+	}
+	for id, h := range t.holds {
+		s.Holds[id] = *h
+	}
+	for id, te := range t.decisions {
+		s.Decisions[id] = snapshotDecision{
+			Account:      newSnapshotAccount(te.Account),
+			Transaction:  *te.Transaction,
+			Hold:         te.Hold,
+			Violations:   te.Violations,
+			Explanations: te.Explanations,
+		}
+	}
+
+	for _, te := range t.Events() {
+		if te.hasViolation() {
+			s.Summary.Rejected++
+		} else {
+			s.Summary.Valid++
+		}
+		s.Summary.Compacted++
+	}
+
+	return s
+}
+
+// Restore replaces the state of the Timeline by the given TimelineSnapshot. The rules and the settings are kept.
+// The Event buffered by LateReorder are not part of the TimelineSnapshot, so they should be flushed before Snapshot.
+// The Timeline has no retained TimelineEvent afterwards, the ones of the snapshotted Timeline are counted by Summary.
+func (t *Timeline) Restore(s TimelineSnapshot) {
+	r := NewTimelineWithRules(t.rules)
+	r.holdExpiry = t.holdExpiry
+	r.tail = t.tail
+	r.idRetention = t.idRetention
+	r.explain = t.explain
+	r.latePolicy = t.latePolicy
+	r.lateness = t.lateness
+	r.horizon = t.horizon
+	r.current = s.Account.account()
+	r.now = time.Time(s.Now)
+	r.watermark = time.Time(s.Watermark)
+	r.summary = s.Summary
+
+	for _, tr := range s.Window {
+		r.remember(tr)
+	}
+	for id, a := range s.Approved {
+		r.approved[id] = &approval{amount: a.Amount, refunded: a.Refunded, reversed: a.Reversed}
+	}
+	for id, h := range s.Holds {
+		held := h
+		r.holds[id] = &held
+	}
+	ids := make([]Transaction, 0, len(s.Decisions))
+	for id, d := range s.Decisions {
+		tr := d.Transaction
+		r.decisions[id] = TimelineEvent{
+			Event:        Event{Account: d.Account.account(), Transaction: &tr, Hold: d.Hold},
+			Violations:   append(make([]Violation, 0, len(d.Violations)), d.Violations...),
+			Explanations: d.Explanations,
+		}
+		ids = append(ids, tr)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		ti, tj := time.Time(ids[i].Time), time.Time(ids[j].Time)
+		return ti.Before(tj) || ti.Equal(tj) && ids[i].ID < ids[j].ID
+	})
+	for _, tr := range ids {
+		r.ids.push(tr)
+	}
+
+	*t = r
+}
+
+// newSnapshotAccount returns the snapshotAccount of the Account. It returns nil when the Account is nil.
+func newSnapshotAccount(acc *Account) *snapshotAccount {
+	if acc == nil {
+		return nil
+	}
+
+	return &snapshotAccount{ID: acc.ID, ActiveCard: acc.ActiveCard, AvailableLimit: acc.AvailableLimit, HeldLimit: acc.HeldLimit}
+}
+
+// account returns the Account of the snapshotAccount. It returns nil when the snapshotAccount is nil.
+func (s *snapshotAccount) account() *Account {
+	if s == nil {
+		return nil
+	}
+
+	return &Account{ID: s.ID, ActiveCard: s.ActiveCard, AvailableLimit: s.AvailableLimit, HeldLimit: s.HeldLimit}
+}
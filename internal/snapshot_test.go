@@ -0,0 +1,217 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSnapshot_RoundTrip splits a stream at many points and checks that restoring the Snapshot of the head and then
+// processing the tail is the same as processing the whole stream, with the settings of each Config.
+func TestSnapshot_RoundTrip(t *testing.T) {
+	explain := DefaultConfig()
+	explain.HoldExpiry, explain.IDRetention = duration(10*time.Minute), duration(20*time.Minute)
+	explain.Explain = true
+	reject := explain
+	reject.LatePolicy, reject.Lateness = LateReject, duration(10*time.Second)
+	reorder := reject
+	reorder.LatePolicy = LateReorder
+	lines := strings.SplitAfter(richStream(6, 3000), "\n")
+
+	// With LateReorder, each Run decides the Event still buffered at its end, so the tail is compared with a Pipeline
+	// that runs the head and then the tail instead of the whole stream at once.
+	cases := []struct {
+		name    string
+		cfg     Config
+		lines   []string
+		resumed bool
+	}{
+		{"explain", explain, lines, false},
+		{"late reject", reject, lateLines(lines, 6), false},
+		{"late reorder", reorder, lateLines(lines, 6), true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg, lines := c.cfg, c.lines
+			newTimeline := func() Timeline { return NewTimelineWithConfig(cfg) }
+
+			full := NewPipeline(3, newTimeline)
+			var want bytes.Buffer
+			if err := full.Run(strings.NewReader(strings.Join(lines, "")), &want); err != nil {
+				t.Fatal(err)
+			}
+			wantLines := strings.SplitAfter(want.String(), "\n")
+
+			for _, at := range []int{0, 1, 7, 500, 1234, 2999, len(lines)} {
+				t.Run(fmt.Sprintf("at=%d", at), func(t *testing.T) {
+					head := NewPipeline(2, newTimeline)
+					if err := head.Run(strings.NewReader(strings.Join(lines[:at], "")), &bytes.Buffer{}); err != nil {
+						t.Fatal(err)
+					}
+					path := filepath.Join(t.TempDir(), "snapshot")
+					if err := SaveSnapshot(path, head.Snapshot(cfg)); err != nil {
+						t.Fatal(err)
+					}
+
+					s, err := LoadSnapshot(path, cfg)
+					if err != nil {
+						t.Fatal(err)
+					}
+					tail := NewPipeline(4, newTimeline)
+					tail.Restore(s)
+					var got bytes.Buffer
+					if err := tail.Run(strings.NewReader(strings.Join(lines[at:], "")), &got); err != nil {
+						t.Fatal(err)
+					}
+
+					want, reference := strings.Join(wantLines[at:], ""), full
+					if c.resumed {
+						reference = NewPipeline(3, newTimeline)
+						var resumed bytes.Buffer
+						if err := reference.Run(strings.NewReader(strings.Join(lines[:at], "")), &bytes.Buffer{}); err != nil {
+							t.Fatal(err)
+						}
+						if err := reference.Run(strings.NewReader(strings.Join(lines[at:], "")), &resumed); err != nil {
+							t.Fatal(err)
+						}
+						want = resumed.String()
+					}
+					if got.String() != want {
+						t.Errorf("at=%d, output differs from the full replay", at)
+					}
+					// Snapshots are compared as files: the Explanation parameters are numbers of another type once loaded.
+					var wantState, gotState bytes.Buffer
+					writeSnapshot(&wantState, reference.Snapshot(cfg))
+					writeSnapshot(&gotState, tail.Snapshot(cfg))
+					if wantState.String() != gotState.String() {
+						t.Errorf("at=%d, state differs from the full replay", at)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestTimeline_Restore(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HistoryTail = 2
+	timeline := NewTimelineWithConfig(cfg)
+	for _, ie := range hfInput {
+		timeline.Process(ie)
+	}
+
+	restored := NewTimelineWithConfig(cfg)
+	restored.Restore(timeline.Snapshot())
+	if got := restored.Events(); len(got) != 0 {
+		t.Errorf("want no TimelineEvent, got: %v", got)
+	}
+	if want, got := (Summary{Compacted: 5, Valid: 4, Rejected: 1}), restored.Summary(); want != got {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	if want, got := timeline.State(), restored.State(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	if want, got := timeline.Window(time.Time{}, timeline.now), restored.Window(time.Time{}, restored.now); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
+
+func TestLoadSnapshot(t *testing.T) {
+	cfg := DefaultConfig()
+	other := cfg
+	other.MaxTransactions = 5
+	tail := cfg
+	tail.HistoryTail = 100
+
+	cases := []struct {
+		name    string
+		content string
+		err     error
+	}{
+		{"same config", fmt.Sprintf(`{"version":%d,"config":%s,"accounts":{}}`, SnapshotVersion, cfg), nil},
+		{"other history tail", fmt.Sprintf(`{"version":%d,"config":%s,"accounts":{}}`, SnapshotVersion, tail), nil},
+		{"other config", fmt.Sprintf(`{"version":%d,"config":%s,"accounts":{}}`, SnapshotVersion, other), ErrInvalidSnapshot},
+		{"other version", fmt.Sprintf(`{"version":%d,"config":%s,"accounts":{}}`, SnapshotVersion+1, cfg), ErrInvalidSnapshot},
+		{"malformed", `{"version":`, ErrInvalidSnapshot},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "snapshot")
+			if err := os.WriteFile(path, []byte(c.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadSnapshot(path, cfg); !errors.Is(err, c.err) {
+				t.Errorf("%s, want: %v, got: %v", c.name, c.err, err)
+			}
+		})
+	}
+
+	if _, err := LoadSnapshot(filepath.Join(t.TempDir(), "missing"), cfg); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("want: %v, got: %v", os.ErrNotExist, err)
+	}
+}
+
+// richStream returns n input lines interleaving the given number of accounts with every kind of Event: Transaction
+// with and without ID and their retries, authorizations that are either captured or expire, reversals, refunds,
+// limit changes and card blocking. Events of each account are 20 seconds apart.
+func richStream(accounts, n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		acc, j := i%accounts, i/accounts
+		id := fmt.Sprintf("account-%d", acc)
+		at := func(j int) string {
+			return time.Time(trTime).Add(time.Duration(j) * 20 * time.Second).Format(time.RFC3339)
+		}
+		tr := func(j int) string {
+			return fmt.Sprintf(`{"id":"%s-%d","account-id":%q,"merchant":"merchant-%d","amount":%d,"time":%q}`,
+				id, j, id, j%5, 1+j%9, at(j))
+		}
+
+		switch {
+		case j == 0:
+			fmt.Fprintf(&sb, `{"account":{"account-id":%q,"active-card":true,"available-limit":1000}}`, id)
+		case j%7 == 1:
+			fmt.Fprintf(&sb, `{"transaction":%s}`, tr(j))
+		case j%7 == 2:
+			fmt.Fprintf(&sb, `{"transaction":%s}`, tr(j-1))
+		case j%7 == 3:
+			fmt.Fprintf(&sb, `{"authorization":%s}`, tr(j))
+		case j%14 == 4:
+			fmt.Fprintf(&sb, `{"capture":{"account-id":%q,"authorization-id":"%s-%d","amount":1,"time":%q}}`, id, id, j-1, at(j))
+		case j%7 == 5:
+			fmt.Fprintf(&sb, `{"refund":{"account-id":%q,"transaction-id":"%s-%d","amount":1,"time":%q}}`, id, id, j-4, at(j))
+		case j%14 == 6:
+			fmt.Fprintf(&sb, `{"reversal":{"account-id":%q,"transaction-id":"%s-%d","time":%q}}`, id, id, j-3, at(j))
+		case j%21 == 0:
+			fmt.Fprintf(&sb, `{"card-blocked":{"account-id":%q}}`, id)
+		case j%21 == 7:
+			fmt.Fprintf(&sb, `{"card-activated":{"account-id":%q}}`, id)
+		default:
+			fmt.Fprintf(&sb, `{"limit-increase":{"account-id":%q,"amount":%d}}`, id, j%3)
+		}
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
+
+// lateLines returns a copy of the lines of richStream with the given number of accounts where every other Transaction
+// with ID comes after the authorization 40 seconds later, so it is late.
+func lateLines(lines []string, accounts int) []string {
+	late := append(make([]string, 0, len(lines)), lines...)
+	for i := range late {
+		if j := i / accounts; j%14 == 1 && i+2*accounts < len(late)-1 {
+			late[i], late[i+2*accounts] = late[i+2*accounts], late[i]
+		}
+	}
+
+	return late
+}
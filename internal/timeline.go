@@ -6,12 +6,22 @@ import (
 )
 
 const (
-	accountAlreadyInitialized = violation("Account-already-initialized")
-	accountNotInitialized     = violation("Account-not-initialized")
-	cardNotActive             = violation("card-not-active")
-	insufficientLimit         = violation("insufficient-limit")
-	highFrequency             = violation("high-frequency-small-interval")
-	doubleTransaction         = violation("double-Transaction")
+	accountAlreadyInitialized = Violation("Account-already-initialized")
+	accountNotInitialized     = Violation("Account-not-initialized")
+	cardNotActive             = Violation("card-not-active")
+	insufficientLimit         = Violation("insufficient-limit")
+	highFrequency             = Violation("high-frequency-small-interval")
+	doubleTransaction         = Violation("double-Transaction")
+	cardAlreadyActive         = Violation("card-already-active")
+	cardAlreadyBlocked        = Violation("card-already-blocked")
+	negativeLimit             = Violation("negative-available-limit")
+	unknownTransaction        = Violation("unknown-transaction")
+	alreadyReversed           = Violation("transaction-already-reversed")
+	refundExceedsAmount       = Violation("refund-exceeds-amount")
+	unknownAuthorization      = Violation("unknown-authorization")
+	captureExceedsAmount      = Violation("capture-exceeds-authorization")
+	transactionIDConflict     = Violation("transaction-id-conflict")
+	lateEvent                 = Violation("late-event")
 )
 
 type (
@@ -19,23 +29,142 @@ type (
 	// It is NOT thread safe and SHOULD NOT be used in concurrent environments.
 	Timeline struct {
 		// events has the internal state of the Timeline.
-		// All TimelineEvent are keep in this slice.
+		// The retained TimelineEvent are keep in this slice, see tail.
 		// This property is not thread safe, does not have any synchronization and SHOULD NOT
 		// be used in concurrent environments.
 		events []TimelineEvent
+		// current is the Account of the last valid TimelineEvent, i.e. the current Account state.
+		// It is nil when the Account is not initialized.
+		current *Account
+		// rules are evaluated for each Transaction Event.
+		rules Rules
+		// recent has the valid Transaction within horizon of the most recent one.
+		recent *window
+		// byMerchant has the same Transaction of recent grouped by Merchant.
+		byMerchant map[string]*window
+		// horizon is the largest window of the rules. With the LateReject policy, it also has the lateness, so the
+		// windows of an accepted late Transaction are complete.
+		horizon time.Duration
+		// approved has the valid Transaction with ID, so they could be reversed or refunded.
+		// Its entries are forgotten with the ones of decisions, see idRetention.
+		approved map[string]*approval
+		// holds has the valid authorizations that were neither captured nor expired.
+		holds map[string]*Transaction
+		// holdExpiry is how long an authorization holds the limit before it is released.
+		holdExpiry time.Duration
+		// now is the latest datetime seen in the Event stream. It only moves forward.
+		now time.Time
+		// decisions has the TimelineEvent of each Transaction (or authorization) with ID, so retries are idempotent.
+		// Its entries are forgotten after idRetention, regardless of tail.
+		decisions map[string]TimelineEvent
+		// ids has the Transaction of decisions in time order, so they are forgotten oldest first.
+		ids *window
+		// idRetention is how long the decisions and approvals are kept. When it is zero, they are never forgotten.
+		idRetention time.Duration
+		// tail is how many TimelineEvent are retained. When it is zero, all of them are retained.
+		tail int
+		// summary counts the TimelineEvent that are no longer retained.
+		summary Summary
+		// explain is true when the TimelineEvent with violations have their Explanation.
+		explain bool
+		// latePolicy is how the Event older than the latest one are handled.
+		latePolicy LatePolicy
+		// lateness is the bound of LateReject and the watermark delay of LateReorder.
+		lateness time.Duration
+		// pending has the Event buffered by LateReorder, in time order.
+		pending []Event
+		// watermark is the datetime before which Event are late when the latePolicy is LateReorder.
+		// It is the latest datetime seen minus lateness, or the datetime of the latest decided Event when it is later.
+		watermark time.Time
+		// decided has the TimelineEvent decided by the last call of Process or Flush, in decision order.
+		decided []TimelineEvent
+	}
+	// Summary groups the counters of the TimelineEvent compacted out of the Timeline and of the forgotten IDs.
+	Summary struct {
+		// Compacted is how many TimelineEvent are no longer retained.
+		Compacted int
+		// Valid is how many of the compacted TimelineEvent were valid.
+		Valid int
+		// Rejected is how many of the compacted TimelineEvent had violations.
+		Rejected int
+		// Forgotten is how many Transaction ID are no longer kept, see Config.IDRetention.
+		Forgotten int
+	}
+	// approval tracks how much of a valid Transaction was already returned.
+	approval struct {
+		// amount is the original amount of the Transaction.
+		amount int
+		// refunded is the sum of all refunds of the Transaction.
+		refunded int
+		// reversed is true when the Transaction was reversed.
+		reversed bool
+	}
+	// lateView is the View of a valid Transaction after a late one, as if the late Transaction was valid before it.
+	// It is used by the LateReject policy, see validateLater.
+	lateView struct {
+		Timeline
+		// late is the late Transaction, counted by the windows that include it.
+		late Transaction
+		// later is the valid Transaction validated again, which is not counted by its own windows.
+		later Transaction
 	}
 )
 
-// NewTimeline creates a new Timeline.
+// NewTimeline creates a new Timeline with DefaultConfig.
 func NewTimeline() Timeline {
-	return Timeline{events: make([]TimelineEvent, 0)}
+	return NewTimelineWithConfig(DefaultConfig())
+}
+
+// NewTimelineWithConfig creates a new Timeline with the Rules and the settings of the given Config.
+func NewTimelineWithConfig(cfg Config) Timeline {
+	t := NewTimelineWithRules(cfg.Rules())
+	t.holdExpiry = time.Duration(cfg.HoldExpiry)
+	t.tail = cfg.HistoryTail
+	t.idRetention = time.Duration(cfg.IDRetention)
+	t.explain = cfg.Explain
+	t.latePolicy = cfg.LatePolicy
+	t.lateness = time.Duration(cfg.Lateness)
+	if t.latePolicy == LateReject {
+		t.horizon += t.lateness
+	}
+
+	return t
+}
+
+// NewTimelineWithRules creates a new Timeline that validates Transaction Event with the given Rules.
+// The other settings are the DefaultConfig ones.
+func NewTimelineWithRules(rules Rules) Timeline {
+	return Timeline{
+		events:     make([]TimelineEvent, 0),
+		rules:      rules,
+		recent:     &window{},
+		byMerchant: make(map[string]*window),
+		horizon:    rules.Window(),
+		approved:   make(map[string]*approval),
+		holds:      make(map[string]*Transaction),
+		holdExpiry: time.Duration(DefaultConfig().HoldExpiry),
+		decisions:  make(map[string]TimelineEvent),
+		ids:        &window{},
+	}
 }
 
-// Events returns all TimelineEvent stored in Timeline.
+// Events returns the TimelineEvent retained in Timeline, oldest first.
+// When the Timeline has a history tail (see Config.HistoryTail), it only returns the last tail TimelineEvent and the
+// older ones are counted by Summary. Otherwise, it returns all of them.
+// Compacted TimelineEvent are not needed by the rules, whose state is kept apart from the history.
 func (t Timeline) Events() []TimelineEvent {
+	if t.tail > 0 && len(t.events) > t.tail {
+		return t.events[len(t.events)-t.tail:]
+	}
+
 	return t.events
 }
 
+// Summary returns the counters of the TimelineEvent that are no longer retained and of the forgotten IDs.
+func (t Timeline) Summary() Summary {
+	return t.summary
+}
+
 // Last returns the last TimelineEvent.
 // This method does not have neither lock strategy nor any kind of synchronization
 // and SHOULD NOT be used in concurrent environments.
@@ -47,21 +176,144 @@ func (t Timeline) Last() *TimelineEvent {
 	return &t.events[len(t.events)-1]
 }
 
-// Process adds an Event into Timeline. It could be either an initialization Event or a Transaction Event.
+// Process adds an Event into Timeline. It could be an initialization Event, a Transaction Event, an authorization
+// Event, a card Event, a limit Event, a Reversal Event, a Refund Event or a Capture Event.
+// When the late policy is LateReorder, the Event could be buffered and decided later, see reorder.
+// The TimelineEvent decided by this call are returned by Decided.
 func (t *Timeline) Process(ie Event) {
-	if !ie.isTransaction() {
-		t.init(*ie.Account)
+	t.decided = t.decided[:0]
+	if t.latePolicy == LateReorder {
+		t.reorder(ie)
+		return
+	}
+
+	t.resolve(ie)
+}
+
+// Flush decides all Event buffered by LateReorder, e.g. at the end of the stream.
+// The TimelineEvent decided by this call are returned by Decided.
+func (t *Timeline) Flush() {
+	t.decided = t.decided[:0]
+	t.release(time.Time{})
+}
+
+// Decided returns the TimelineEvent decided by the last call of Process or Flush, in decision order.
+// Unless the late policy is LateReorder, it is always the Last TimelineEvent of Process.
+// The returned slice is reused by the next call of Process or Flush.
+func (t Timeline) Decided() []TimelineEvent {
+	return t.decided
+}
+
+// resolve handles the Event right away and records its TimelineEvent as decided.
+func (t *Timeline) resolve(ie Event) {
+	t.handle(ie)
+	t.decided = append(t.decided, *t.Last())
+}
+
+// reorder buffers the timed Event until the watermark passes them, so they are decided in time order even when they
+// arrive out of order within lateness. Event without datetime release all buffered Event before being decided, since
+// their order relative to them is only known by the input order. Event older than the watermark are rejected with a
+// lateEvent violation, because later Event were already decided. Retries of a decided Transaction are answered right
+// away with the original decision, as with any other policy, see replay.
+func (t *Timeline) reorder(ie Event) {
+	if ie.isTransaction() && t.replay(*ie.Transaction, ie.Hold) {
+		t.decided = append(t.decided, *t.Last())
 		return
 	}
 
-	t.add(*ie.Transaction)
+	at := ie.time()
+	switch {
+	case at.IsZero():
+		t.release(time.Time{})
+		t.resolve(ie)
+	case at.Before(t.watermark):
+		t.reject(ie)
+		t.decided = append(t.decided, *t.Last())
+	default:
+		i := sort.Search(len(t.pending), func(i int) bool { return t.pending[i].time().After(at) })
+		t.pending = append(t.pending, Event{})
+		copy(t.pending[i+1:], t.pending[i:])
+		t.pending[i] = ie
+		if wm := at.Add(-t.lateness); wm.After(t.watermark) {
+			t.watermark = wm
+		}
+		t.release(t.watermark)
+	}
+}
+
+// release decides the buffered Event at or before until, in time order. When until is zero, all of them are decided
+// and the watermark moves to the latest of them.
+func (t *Timeline) release(until time.Time) {
+	n := len(t.pending)
+	if !until.IsZero() {
+		n = sort.Search(len(t.pending), func(i int) bool { return t.pending[i].time().After(until) })
+	}
+	if n == 0 {
+		return
+	}
+
+	for _, ie := range t.pending[:n] {
+		t.resolve(ie)
+	}
+	if last := t.pending[n-1].time(); last.After(t.watermark) {
+		t.watermark = last
+	}
+	t.pending = append(t.pending[:0], t.pending[n:]...)
+}
+
+// late returns true when the late policy is LateReject and the Event is older than the latest one by more than
+// lateness. Event without datetime are never late.
+func (t Timeline) late(ie Event) bool {
+	at := ie.time()
+
+	return t.latePolicy == LateReject && !at.IsZero() && at.Before(t.now.Add(-t.lateness))
+}
+
+// reject puts the Event into TimelineEvent with a lateEvent violation plus the last valid Account state.
+// It does not change the Timeline state, so a late Transaction with ID is not a decision to be replayed.
+func (t *Timeline) reject(ie Event) {
+	te := TimelineEvent{Event: ie, Violations: []Violation{lateEvent}}
+	te.Account = t.state()
+	t.append(te)
+}
+
+// handle dispatches the Event to its handler.
+// Before that, the authorizations that expired until the Event datetime are released.
+// A Transaction with the ID of a previous one is either a replay or a conflict, see replay.
+// Late Event are rejected, see late.
+func (t *Timeline) handle(ie Event) {
+	if ie.isTransaction() && t.replay(*ie.Transaction, ie.Hold) {
+		return
+	}
+	if t.late(ie) {
+		t.reject(ie)
+		return
+	}
+	t.advance(ie.time())
+
+	switch {
+	case ie.isTransaction():
+		t.add(*ie.Transaction, ie.Hold)
+	case ie.Card != nil:
+		t.card(*ie.Card)
+	case ie.Limit != nil:
+		t.limit(*ie.Limit)
+	case ie.Reversal != nil:
+		t.reverse(*ie.Reversal)
+	case ie.Refund != nil:
+		t.refund(*ie.Refund)
+	case ie.Capture != nil:
+		t.capture(*ie.Capture)
+	default:
+		t.init(*ie.Account)
+	}
 }
 
 // init handles initialization Event. Those Event should not have Transaction, only Account.
 // If an initialization was done before, it will put it into TimelineEvent with an accountAlreadyInitialized violation
 // plus the last valid Account state.
 func (t *Timeline) init(acc Account) {
-	violations := make([]violation, 0)
+	violations := make([]Violation, 0)
 
 	newState := acc
 	if initAcc := t.state(); initAcc != nil {
@@ -69,7 +321,7 @@ func (t *Timeline) init(acc Account) {
 		newState = *initAcc
 	}
 
-	t.events = append(t.events, TimelineEvent{
+	t.append(TimelineEvent{
 		Event: Event{
 			Account:     &newState,
 			Transaction: nil,
@@ -80,126 +332,517 @@ func (t *Timeline) init(acc Account) {
 
 // add handles Transaction Event.
 // It performs a series of validations before put it into TimelineEvent.
+// When hold is true, the Transaction is an authorization and its amount is held until it is captured or expired.
 // See README.md for more details.
-func (t *Timeline) add(tr Transaction) {
+func (t *Timeline) add(tr Transaction, hold bool) {
 	lastState := t.state()
-	availableLimit := 0
-	if lastState != nil {
-		availableLimit = lastState.AvailableLimit
+	violations, explanations := t.validate(tr)
+	if len(violations) == 0 && t.latePolicy == LateReject {
+		violations, explanations = t.validateLater(tr)
 	}
-	violations := t.validate(tr, availableLimit)
 
 	if len(violations) > 0 {
 		oe := TimelineEvent{
 			Event: Event{
 				Account:     lastState,
 				Transaction: &tr,
+				Hold:        hold,
 			},
-			Violations: violations,
+			Violations:   violations,
+			Explanations: explanations,
 		}
-		t.events = append(t.events, oe)
+		t.decide(oe)
 		return
 	}
 
-	newState := Account{
-		ActiveCard:     true,
-		AvailableLimit: availableLimit - tr.Amount,
+	newState := Account{}
+	if lastState != nil {
+		newState = *lastState
+	}
+	newState.AvailableLimit -= tr.Amount
+	t.remember(tr)
+	switch {
+	case hold:
+		newState.HeldLimit += tr.Amount
+		held := tr
+		t.holds[tr.ID] = &held
+	case tr.ID != "":
+		t.approved[tr.ID] = &approval{amount: tr.Amount}
 	}
 	oe := TimelineEvent{
 		Event: Event{
 			Account:     &newState,
 			Transaction: &tr,
+			Hold:        hold,
 		},
 		Violations: violations,
 	}
-	t.events = append(t.events, oe)
+	t.decide(oe)
 }
 
-// validate performs a series of validations in the Transaction Event.
-// See README.md for more details.
-func (t Timeline) validate(tr Transaction, availableLimit int) []violation {
-	const maxAllowedHF = 3
-	const maxAllowedDT = 1
-	const minIntervalAllowed = 2
-	betweenFilter := func(e Event) bool {
-		diff := time.Time(tr.Time).Sub(time.Time(e.Time))
-		return diff.Minutes() <= minIntervalAllowed
+// decide puts the TimelineEvent of a Transaction into Timeline and keeps it when the Transaction has ID.
+func (t *Timeline) decide(oe TimelineEvent) {
+	if id := oe.Transaction.ID; id != "" {
+		t.decisions[id] = oe
+		t.ids.push(*oe.Transaction)
+	}
+	t.append(oe)
+}
+
+// replay handles Transaction with the ID of a previous one and returns true when it was handled.
+// When the content is the same, it puts a copy of the previous decision into TimelineEvent with Replay true.
+// Otherwise, it puts it into TimelineEvent with a transactionIDConflict violation plus the last valid Account state.
+// Neither of them changes the Timeline state.
+func (t *Timeline) replay(tr Transaction, hold bool) bool {
+	if tr.ID == "" {
+		return false
 	}
-	betweenFilterSameMerchant := func(e Event) bool {
-		return betweenFilter(e) && e.Merchant == tr.Merchant
+	original, ok := t.decisions[tr.ID]
+	if !ok {
+		return false
 	}
-	violations := make([]violation, 0)
 
-	if lastInitializedAccount := t.state(); lastInitializedAccount == nil {
-		return append(violations, accountNotInitialized)
+	if original.Hold != hold || !original.Transaction.equal(tr) {
+		t.append(TimelineEvent{
+			Event: Event{
+				Account:     t.state(),
+				Transaction: &tr,
+				Hold:        hold,
+			},
+			Violations: []Violation{transactionIDConflict},
+		})
+		return true
 	}
 
-	if lastCardActive := t.activeState(); lastCardActive == nil {
-		return append(violations, cardNotActive)
+	oe := original
+	oe.Violations = append(make([]Violation, 0, len(original.Violations)), original.Violations...)
+	oe.Replay = true
+	t.append(oe)
+
+	return true
+}
+
+// card handles card activation and blocking Event.
+// If the Account is not initialized or its card is already in the requested state, it will put it into
+// TimelineEvent with the respective violation plus the last valid Account state.
+func (t *Timeline) card(cs CardStatus) {
+	violations := make([]Violation, 0)
+
+	lastState := t.state()
+	switch {
+	case lastState == nil:
+		violations = append(violations, accountNotInitialized)
+	case cs.Active && lastState.ActiveCard:
+		violations = append(violations, cardAlreadyActive)
+	case !cs.Active && !lastState.ActiveCard:
+		violations = append(violations, cardAlreadyBlocked)
 	}
 
-	if tr.Amount > availableLimit {
-		violations = append(violations, insufficientLimit)
+	newState := lastState
+	if len(violations) == 0 {
+		acc := *lastState
+		acc.ActiveCard = cs.Active
+		newState = &acc
 	}
 
-	if t.count(betweenFilter) >= maxAllowedHF {
-		violations = append(violations, highFrequency)
+	t.append(TimelineEvent{
+		Event: Event{
+			Account: newState,
+			Card:    &cs,
+		},
+		Violations: violations,
+	})
+}
+
+// limit handles limit adjustment Event.
+// If the Account is not initialized or the adjustment would make the available limit negative, it will put it into
+// TimelineEvent with the respective violation plus the last valid Account state.
+func (t *Timeline) limit(lc LimitChange) {
+	violations := make([]Violation, 0)
+
+	lastState := t.state()
+	newState := lastState
+	if lastState == nil {
+		violations = append(violations, accountNotInitialized)
+	} else {
+		acc := *lastState
+		switch lc.Operation {
+		case LimitIncrease:
+			acc.AvailableLimit += lc.Amount
+		case LimitDecrease:
+			acc.AvailableLimit -= lc.Amount
+		case LimitSet:
+			acc.AvailableLimit = lc.Amount
+		}
+
+		if acc.AvailableLimit < 0 {
+			violations = append(violations, negativeLimit)
+		} else {
+			newState = &acc
+		}
 	}
 
-	if t.count(betweenFilterSameMerchant) >= maxAllowedDT {
-		violations = append(violations, doubleTransaction)
+	t.append(TimelineEvent{
+		Event: Event{
+			Account: newState,
+			Limit:   &lc,
+		},
+		Violations: violations,
+	})
+}
+
+// reverse handles Reversal Event. It restores the amount of the Transaction that was not refunded yet.
+// If the Account is not initialized, the Transaction is unknown or already reversed, it will put it into
+// TimelineEvent with the respective violation plus the last valid Account state.
+func (t *Timeline) reverse(r Reversal) {
+	lastState := t.state()
+	a, violations := t.approval(lastState, r.TransactionID)
+	newState := lastState
+	if len(violations) == 0 {
+		acc := *lastState
+		acc.AvailableLimit += a.amount - a.refunded
+		a.reversed = true
+		newState = &acc
 	}
 
-	return violations
+	t.append(TimelineEvent{
+		Event: Event{
+			Account:  newState,
+			Reversal: &r,
+		},
+		Violations: violations,
+	})
 }
 
-// count returns how many valid Transaction are inside the Timeline according the given function filter.
-func (t Timeline) count(filter func(event Event) bool) (count int) {
-	for _, outputEvent := range t.events {
-		if outputEvent.isTransaction() && !outputEvent.hasViolation() && filter(outputEvent.Event) {
-			count++
-		}
+// refund handles Refund Event. It restores the refunded amount.
+// If the Account is not initialized, the Transaction is unknown or already reversed, or the refunds exceed the
+// Transaction amount, it will put it into TimelineEvent with the respective violation plus the last valid Account state.
+func (t *Timeline) refund(r Refund) {
+	lastState := t.state()
+	a, violations := t.approval(lastState, r.TransactionID)
+	if len(violations) == 0 && r.Amount > a.amount-a.refunded {
+		violations = append(violations, refundExceedsAmount)
+	}
+
+	newState := lastState
+	if len(violations) == 0 {
+		acc := *lastState
+		acc.AvailableLimit += r.Amount
+		a.refunded += r.Amount
+		newState = &acc
 	}
 
-	return
+	t.append(TimelineEvent{
+		Event: Event{
+			Account: newState,
+			Refund:  &r,
+		},
+		Violations: violations,
+	})
 }
 
-// state returns the current Account state. It could be either active or inactive.
-func (t Timeline) state() *Account {
-	return t.stateByFilter(func(events []TimelineEvent, i int) bool {
-		return events[i].Account != nil
+// capture handles Capture Event. It settles the Capture amount and releases the rest of the authorization.
+// The captured authorization becomes a valid Transaction that could be reversed or refunded.
+// If the Account is not initialized, the authorization is unknown (or expired) or the Capture amount exceeds the
+// authorization amount, it will put it into TimelineEvent with the respective violation plus the last valid Account state.
+func (t *Timeline) capture(c Capture) {
+	violations := make([]Violation, 0)
+
+	lastState := t.state()
+	h, ok := t.holds[c.AuthorizationID]
+	switch {
+	case lastState == nil:
+		violations = append(violations, accountNotInitialized)
+	case !ok:
+		violations = append(violations, unknownAuthorization)
+	case c.Amount > h.Amount:
+		violations = append(violations, captureExceedsAmount)
+	}
+
+	newState := lastState
+	if len(violations) == 0 {
+		acc := *lastState
+		acc.HeldLimit -= h.Amount
+		acc.AvailableLimit += h.Amount - c.Amount
+		delete(t.holds, c.AuthorizationID)
+		t.approved[c.AuthorizationID] = &approval{amount: c.Amount}
+		newState = &acc
+	}
+
+	t.append(TimelineEvent{
+		Event: Event{
+			Account: newState,
+			Capture: &c,
+		},
+		Violations: violations,
 	})
 }
 
+// advance moves the Timeline clock forward to now and releases the authorizations held for longer than holdExpiry,
+// see expire. Then, the IDs kept for longer than idRetention are forgotten, see forget.
+// Zero or past datetimes do not move the clock. The clock only moves on the Event of this Timeline, i.e. of its
+// Account, the Event of other Accounts release its authorizations through Expire.
+func (t *Timeline) advance(now time.Time) {
+	if !now.After(t.now) {
+		return
+	}
+	t.now = now
+	t.expire(now)
+	t.forget(now)
+}
 
-// activeState returns the last active state.
-func (t Timeline) activeState() *Account {
-	return t.stateByFilter(func(te []TimelineEvent, i int) bool {
-		return te[i].Account != nil && te[i].ActiveCard
+// Expire releases the authorizations held for longer than holdExpiry at now, e.g. the datetime of the latest Event of
+// the whole stream, so an Account without Event of its own does not keep its held limit forever. Unlike the Event of
+// this Timeline, it does not move its clock, so it neither forgets IDs nor changes which Event are late.
+// With the LateReorder policy, it does not go beyond the watermark, since the buffered Event are decided before.
+func (t *Timeline) Expire(now time.Time) {
+	if t.latePolicy == LateReorder && now.After(t.watermark) {
+		now = t.watermark
+	}
+	if now.After(t.now) {
+		t.expire(now)
+	}
+}
+
+// expire releases the authorizations held for longer than holdExpiry at now, in time order.
+// Each released authorization is put into TimelineEvent as a valid Event with Expired present.
+func (t *Timeline) expire(now time.Time) {
+	expired := make([]*Transaction, 0)
+	for _, h := range t.holds {
+		if now.Sub(time.Time(h.Time)) > t.holdExpiry {
+			expired = append(expired, h)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		ti, tj := time.Time(expired[i].Time), time.Time(expired[j].Time)
+		return ti.Before(tj) || ti.Equal(tj) && expired[i].ID < expired[j].ID
 	})
+
+	for _, h := range expired {
+		acc := *t.state()
+		acc.HeldLimit -= h.Amount
+		acc.AvailableLimit += h.Amount
+		delete(t.holds, h.ID)
+
+		t.append(TimelineEvent{
+			Event: Event{
+				Account: &acc,
+				Expired: h,
+			},
+			Violations: make([]Violation, 0),
+		})
+	}
 }
 
-// stateByFilter returns a state according a given function filter.
-// It makes a copy of the current timeline, sort its in descending order and returns the first match.
-// It returns nil if no state is found.
-func (t Timeline) stateByFilter(filter func(te []TimelineEvent, i int) bool) *Account {
-	if len(t.events) <= 0 {
-		return nil
+// forget removes the decisions and approvals of the Transaction older than now by more than idRetention, so a later
+// Transaction with the same ID is a new one and a later return of it is an unknownTransaction.
+// Since idRetention is at least holdExpiry, the authorizations among them were already captured or expired.
+func (t *Timeline) forget(now time.Time) {
+	if t.idRetention <= 0 {
+		return
+	}
+
+	for _, tr := range t.ids.evict(now.Add(-t.idRetention)) {
+		delete(t.decisions, tr.ID)
+		delete(t.approved, tr.ID)
+		t.summary.Forgotten++
 	}
-	sortedEvents := make([]TimelineEvent, len(t.events))
-	copy(sortedEvents, t.events)
-	sort.Slice(sortedEvents, func(i, j int) bool { return i > j })
-	filterValidEvents := func(j int) bool {
-		return filter(sortedEvents, j) && !sortedEvents[j].hasViolation()
+}
+
+// approval returns the approval of the given Transaction ID and the violations that prevent returning it.
+// The returned slice is never nil.
+func (t Timeline) approval(acc *Account, id string) (*approval, []Violation) {
+	violations := make([]Violation, 0)
+	if acc == nil {
+		return nil, append(violations, accountNotInitialized)
 	}
 
-	i := 0
-	if !filterValidEvents(0) {
-		i = sort.Search(len(sortedEvents), filterValidEvents)
+	a, ok := t.approved[id]
+	switch {
+	case !ok:
+		violations = append(violations, unknownTransaction)
+	case a.reversed:
+		violations = append(violations, alreadyReversed)
 	}
 
-	if i == len(sortedEvents) {
+	return a, violations
+}
+
+// validate performs a series of validations in the Transaction Event according the Timeline Rules.
+// When explain is true, it also returns the Explanation of the violations. Otherwise, it returns nil Explanation.
+// See README.md for more details.
+func (t Timeline) validate(tr Transaction) ([]Violation, []Explanation) {
+	if !t.explain {
+		return t.rules.Validate(t, t.state(), tr), nil
+	}
+
+	explanations := t.rules.Explain(t, t.state(), tr)
+	violations := make([]Violation, 0, len(explanations))
+	for _, e := range explanations {
+		violations = append(violations, e.Violation)
+	}
+
+	return violations, explanations
+}
+
+// validateLater performs the validations of the WindowedRule again for each valid Transaction after tr within their
+// window, as if tr was valid before them, so a late Transaction cannot push the window of a later one over its limit.
+// It returns the violations of the first later Transaction that breaches each Rule, see validate for Explanation.
+// The Transaction of their Explanation are the ones counted for the later Transaction, followed by it.
+func (t Timeline) validateLater(tr Transaction) ([]Violation, []Explanation) {
+	violations := make([]Violation, 0)
+	var explanations []Explanation
+	if t.explain {
+		explanations = make([]Explanation, 0)
+	}
+	at := time.Time(tr.Time)
+	for _, r := range t.rules {
+		if g, ok := r.(guard); ok {
+			r = g.Rule
+		}
+		wr, ok := r.(WindowedRule)
+		if !ok {
+			continue
+		}
+		for _, later := range t.recent.between(at, at.Add(wr.Window())) {
+			if !time.Time(later.Time).After(at) {
+				continue
+			}
+			v := lateView{Timeline: t, late: tr, later: later}
+			vs := wr.Validate(v, t.state(), later)
+			if len(vs) == 0 {
+				continue
+			}
+			violations = append(violations, vs...)
+			for _, vi := range vs {
+				if e, ok := wr.(Explainer); ok && t.explain {
+					ex := e.Explain(v, t.state(), later, vi)
+					ex.Transactions = append(ex.Transactions, later)
+					explanations = append(explanations, ex)
+				} else if t.explain {
+					explanations = append(explanations, Explanation{Violation: vi, Account: t.state()})
+				}
+			}
+			break
+		}
+	}
+
+	return violations, explanations
+}
+
+// Window implements View interface.
+func (t Timeline) Window(since, until time.Time) []Transaction {
+	return t.recent.between(since, until)
+}
+
+// MerchantWindow implements View interface.
+func (t Timeline) MerchantWindow(merchant string, since, until time.Time) []Transaction {
+	w, ok := t.byMerchant[merchant]
+	if !ok {
 		return nil
 	}
-	return sortedEvents[i].Account
+
+	return w.between(since, until)
+}
+
+// Window implements View interface.
+func (v lateView) Window(since, until time.Time) []Transaction {
+	return v.adjust(v.Timeline.Window(since, until), since, until, true)
+}
+
+// MerchantWindow implements View interface.
+func (v lateView) MerchantWindow(merchant string, since, until time.Time) []Transaction {
+	return v.adjust(v.Timeline.MerchantWindow(merchant, since, until), since, until, v.late.Merchant == merchant)
+}
+
+// adjust returns a copy of the Transaction of a window from since to until without the later Transaction. When
+// withLate is true, the late Transaction is put in time order into it as well, if it is within the window.
+func (v lateView) adjust(txs []Transaction, since, until time.Time, withLate bool) []Transaction {
+	at := time.Time(v.late.Time)
+	withLate = withLate && !at.Before(since) && !at.After(until)
+	adjusted := make([]Transaction, 0, len(txs)+1)
+	for _, tx := range txs {
+		if withLate && time.Time(tx.Time).After(at) {
+			adjusted = append(adjusted, v.late)
+			withLate = false
+		}
+		if tx != v.later {
+			adjusted = append(adjusted, tx)
+		}
+	}
+	if withLate {
+		adjusted = append(adjusted, v.late)
+	}
+
+	return adjusted
+}
+
+// remember puts a valid Transaction into the windows and evicts the ones older than horizon.
+// Merchants without any recent Transaction are removed, so memory is bounded by the windows, not by the history.
+// Evicted Transaction are no longer counted by the rules, even by a late Transaction whose window would include them,
+// and a late Transaction older than horizon is evicted right away. See Config.LatePolicy for late Event.
+func (t *Timeline) remember(tr Transaction) {
+	t.recent.push(tr)
+	w, ok := t.byMerchant[tr.Merchant]
+	if !ok {
+		w = &window{}
+		t.byMerchant[tr.Merchant] = w
+	}
+	w.push(tr)
+
+	before := t.recent.latest().Add(-t.horizon)
+	for _, old := range t.recent.evict(before) {
+		if w := t.byMerchant[old.Merchant]; w != nil {
+			if w.evict(before); w.len() == 0 {
+				delete(t.byMerchant, old.Merchant)
+			}
+		}
+	}
+}
+
+// State returns the current Account state. It returns nil when the Account is not initialized.
+func (t Timeline) State() *Account {
+	return t.state()
+}
+
+// state returns the current Account state. It could be either active or inactive.
+// It does not walk the timeline, the state is kept up to date by append.
+func (t Timeline) state() *Account {
+	return t.current
+}
+
+// append puts the TimelineEvent into Timeline.
+// When it is valid, it is not a replay and it has Account, its Account becomes the current state.
+// When explain is true, violations that were not explained by the rules are explained by the current Account state.
+func (t *Timeline) append(te TimelineEvent) {
+	if te.Account != nil && !te.hasViolation() && !te.Replay {
+		t.current = te.Account
+	}
+	if t.explain && te.hasViolation() && te.Explanations == nil {
+		te.Explanations = make([]Explanation, 0, len(te.Violations))
+		for _, v := range te.Violations {
+			te.Explanations = append(te.Explanations, Explanation{Violation: v, Account: t.current})
+		}
+	}
+	t.events = append(t.events, te)
+	t.compact()
+}
+
+// compact counts the TimelineEvent that just left the tail into summary.
+// The storage of the compacted TimelineEvent is only released when it doubles the tail, so it is amortized O(1).
+func (t *Timeline) compact() {
+	if t.tail <= 0 || len(t.events) <= t.tail {
+		return
+	}
+
+	if old := t.events[len(t.events)-1-t.tail]; old.hasViolation() {
+		t.summary.Rejected++
+	} else {
+		t.summary.Valid++
+	}
+	t.summary.Compacted++
+
+	if len(t.events) >= 2*t.tail {
+		t.events = append(make([]TimelineEvent, 0, 2*t.tail), t.events[len(t.events)-t.tail:]...)
+	}
 }
@@ -1,39 +1,277 @@
 package internal
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
 )
 
+// processCases are the scenarios of TestTimeline_Process. Each case has its own Timeline.
+var processCases = []struct {
+	name string
+	in   []Event
+	want []TimelineEvent
+}{
+	{"successful-initialization", iaInput, iaOutput},
+	{"successful-Transaction", sfInput, sfOutput},
+	{"Account-already-initialized", aaiInput, aaiOutput},
+	{"Account-not-initialized", aniInput, aniOutput},
+	{"card-not-active", cnaInput, cnaOutput},
+	{"insufficient-limit", ilInput, ilOutput},
+	{"high-frequency-small-interval", hfInput, hfOutput},
+	{"double-Transaction", dtInput, dtOutput},
+	{"successful-transactions-after-hf-violation", stavInput, stavOutput},
+	{"successful-transactions-after-dt-violation", stadtvInput, stadtvOutput},
+	{"card-activated", caInput, caOutput},
+	{"card-blocked", cbInput, cbOutput},
+	{"card-already-active", caaInput, caaOutput},
+	{"card-already-blocked", cabInput, cabOutput},
+	{"limit-changes", lcInput, lcOutput},
+	{"negative-available-limit", nlInput, nlOutput},
+	{"reversal-and-refund", rrInput, rrOutput},
+	{"returns-violations", rvInput, rvOutput},
+	{"authorization-and-capture", acInput, acOutput},
+	{"authorization-expiry", aeInput, aeOutput},
+	{"idempotent-replay", irInput, irOutput},
+	{"transaction-id-conflict", icInput, icOutput},
+}
+
 func TestTimeline_Process(t *testing.T) {
+	for _, c := range processCases {
+		t.Run(c.name, func(t *testing.T) {
+			timeline := NewTimeline()
+
+			for _, ie := range c.in {
+				timeline.Process(ie)
+			}
+
+			if got := timeline.Events(); !reflect.DeepEqual(c.want, got) {
+				t.Errorf("%s, want: %v, got: %v", c.name, c.want, got)
+			}
+		})
+	}
+}
+
+// TestTimeline_Process_Explain checks that explain mode only adds one Explanation per violation, in the same order.
+func TestTimeline_Process_Explain(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Explain = true
+
+	for _, c := range processCases {
+		t.Run(c.name, func(t *testing.T) {
+			timeline := NewTimelineWithConfig(cfg)
+
+			for _, ie := range c.in {
+				timeline.Process(ie)
+			}
+
+			got := timeline.Events()
+			for i, te := range got {
+				if want := len(te.Violations); len(te.Explanations) != want {
+					t.Fatalf("%s, event %d, want: %d explanations, got: %+v", c.name, i, want, te.Explanations)
+				}
+				for j, e := range te.Explanations {
+					if e.Violation != te.Violations[j] {
+						t.Errorf("%s, event %d, want: %s, got: %s", c.name, i, te.Violations[j], e.Violation)
+					}
+				}
+				got[i].Explanations = nil
+			}
+			if !reflect.DeepEqual(c.want, got) {
+				t.Errorf("%s, want: %v, got: %v", c.name, c.want, got)
+			}
+		})
+	}
+}
+
+func TestNewTimelineWithConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HoldExpiry = duration(time.Hour)
+	timeline := NewTimelineWithConfig(cfg)
+	timeline.Process(Event{Account: &Account{ActiveCard: true, AvailableLimit: 100}})
+	timeline.Process(Event{Transaction: &acAuth, Hold: true})
+	timeline.Process(Event{Refund: &Refund{TransactionID: "t1", Time: datetime(time.Time(trTime).Add(time.Hour))}})
+	if got := timeline.State().HeldLimit; got != 70 {
+		t.Errorf("want: %d, got: %d", 70, got)
+	}
+
+	timeline.Process(Event{Refund: &Refund{TransactionID: "t1", Time: datetime(time.Time(trTime).Add(time.Hour + time.Second))}})
+	if want, got := (Account{ActiveCard: true, AvailableLimit: 100}), *timeline.State(); want != got {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
+
+func TestTimeline_Expire(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy LatePolicy
+		held   int
+	}{
+		{"accept", LateAccept, 0},
+		{"reject", LateReject, 0},
+		{"reorder", LateReorder, 70},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.LatePolicy, cfg.Lateness = c.policy, duration(time.Minute)
+			timeline := NewTimelineWithConfig(cfg)
+			timeline.Process(Event{Account: &Account{ActiveCard: true, AvailableLimit: 100}})
+			timeline.Process(Event{Transaction: &acAuth, Hold: true})
+			timeline.Flush()
+
+			timeline.Expire(time.Time(aeTime))
+			if got := timeline.State().HeldLimit; got != c.held {
+				t.Errorf("%s, want: %d, got: %d", c.name, c.held, got)
+			}
+			if got := timeline.now; !got.Equal(time.Time(trTime)) {
+				t.Errorf("%s, want clock: %v, got: %v", c.name, trTime, got)
+			}
+		})
+	}
+}
+
+// TestTimeline_Process_IDRetention checks that the decision and the approval of a Transaction with ID are forgotten
+// once the Timeline clock passes its datetime by more than the retention, so a retry is a new Transaction.
+func TestTimeline_Process_IDRetention(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HoldExpiry, cfg.IDRetention = duration(time.Hour), duration(2*time.Hour)
+	timeline := NewTimelineWithConfig(cfg)
+	at := func(d time.Duration) datetime { return datetime(time.Time(trTime).Add(d)) }
+	retained := Transaction{ID: "t1", Merchant: "Colorado Rockies", Amount: 10, Time: trTime}
+
+	cases := []struct {
+		name       string
+		in         Event
+		violations []Violation
+		replay     bool
+		limit      int
+	}{
+		{"account", Event{Account: &Account{ActiveCard: true, AvailableLimit: 100}}, nil, false, 100},
+		{"transaction", Event{Transaction: &retained}, nil, false, 90},
+		{"retry within retention", Event{Transaction: &retained}, nil, true, 90},
+		{"refund within retention", Event{Refund: &Refund{TransactionID: "t1", Amount: 5, Time: at(2 * time.Hour)}}, nil, false, 95},
+		{"later transaction", Event{Transaction: &Transaction{ID: "t2", Merchant: "Miami Marlins", Amount: 10, Time: at(2*time.Hour + time.Second)}},
+			nil, false, 85},
+		{"refund after retention", Event{Refund: &Refund{TransactionID: "t1", Amount: 5, Time: at(2*time.Hour + time.Second)}},
+			[]Violation{unknownTransaction}, false, 85},
+		{"retry after retention", Event{Transaction: &retained}, nil, false, 75},
+	}
+
+	for _, c := range cases {
+		timeline.Process(c.in)
+		te := timeline.Last()
+		if len(c.violations) != len(te.Violations) || len(c.violations) > 0 && !reflect.DeepEqual(c.violations, te.Violations) {
+			t.Errorf("%s, want: %v, got: %v", c.name, c.violations, te.Violations)
+		}
+		if te.Replay != c.replay {
+			t.Errorf("%s, want replay: %v, got: %v", c.name, c.replay, te.Replay)
+		}
+		if got := timeline.State().AvailableLimit; got != c.limit {
+			t.Errorf("%s, want: %d, got: %d", c.name, c.limit, got)
+		}
+	}
+	if want, got := 1, timeline.Summary().Forgotten; want != got {
+		t.Errorf("want: %d forgotten, got: %d", want, got)
+	}
+	if _, ok := timeline.decisions["t2"]; !ok {
+		t.Errorf("want decision of %q kept", "t2")
+	}
+}
+
+// TestTimeline_Process_RefundOverflow checks that a refund large enough to overflow the refunded amount still exceeds
+// the Transaction amount.
+func TestTimeline_Process_RefundOverflow(t *testing.T) {
+	timeline := NewTimeline()
+	timeline.Process(Event{Account: &Account{ActiveCard: true, AvailableLimit: 100}})
+	timeline.Process(Event{Transaction: &rrTr})
+	timeline.Process(Event{Refund: &Refund{TransactionID: "t1", Amount: 5, Time: trTime}})
+	timeline.Process(Event{Refund: &Refund{TransactionID: "t1", Amount: int(^uint(0) >> 1), Time: trTime}})
+
+	if want, got := []Violation{refundExceedsAmount}, timeline.Last().Violations; !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	if want, got := (Account{ActiveCard: true, AvailableLimit: 45}), *timeline.State(); want != got {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
+
+// BenchmarkTimeline_Process_State processes streams of state Event of increasing length.
+// Each Event reads the current state, so ns/event must stay flat as the stream grows.
+func BenchmarkTimeline_Process_State(b *testing.B) {
+	for _, n := range []int{1000, 10000, 100000, 1000000} {
+		stream := stateStream(n)
+		b.Run(fmt.Sprintf("events=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			start := time.Now()
+			for i := 0; i < b.N; i++ {
+				timeline := NewTimeline()
+				for _, ie := range stream {
+					timeline.Process(ie)
+				}
+			}
+			b.ReportMetric(float64(time.Since(start).Nanoseconds())/float64(b.N*n), "ns/event")
+		})
+	}
+}
+
+// BenchmarkTimeline_Process_Transactions processes streams of Transaction of increasing length.
+// Velocity rules only look at the window, so ns/event must stay flat as the history grows.
+func BenchmarkTimeline_Process_Transactions(b *testing.B) {
+	for _, n := range []int{1000, 10000, 100000, 1000000} {
+		stream := transactionStream(n)
+		b.Run(fmt.Sprintf("events=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			start := time.Now()
+			for i := 0; i < b.N; i++ {
+				timeline := NewTimeline()
+				for _, ie := range stream {
+					timeline.Process(ie)
+				}
+			}
+			b.ReportMetric(float64(time.Since(start).Nanoseconds())/float64(b.N*n), "ns/event")
+		})
+	}
+}
+
+func TestTimeline_Events_HistoryTail(t *testing.T) {
 	cases := []struct {
 		name string
+		tail int
 		in   []Event
-		want []TimelineEvent
+		want Summary
 	}{
-		{"successful-initialization", iaInput, iaOutput},
-		{"successful-Transaction", sfInput, sfOutput},
-		{"Account-already-initialized", aaiInput, aaiOutput},
-		{"Account-not-initialized", aniInput, aniOutput},
-		{"card-not-active", cnaInput, cnaOutput},
-		{"insufficient-limit", ilInput, ilOutput},
-		{"high-frequency-small-interval", hfInput, hfOutput},
-		{"double-Transaction", dtInput, dtOutput},
-		{"successful-transactions-after-hf-violation", stavInput, stavOutput},
-		{"successful-transactions-after-dt-violation", stadtvInput, stadtvOutput},
+		{"without tail", 0, hfInput, Summary{}},
+		{"shorter than tail", 10, hfInput, Summary{}},
+		{"same as tail", 5, hfInput, Summary{}},
+		{"longer than tail", 2, hfInput, Summary{Compacted: 3, Valid: 3, Rejected: 0}},
+		{"twice the tail", 2, append(append([]Event{}, hfInput...), hfInput[4], hfInput[4]), Summary{Compacted: 5, Valid: 4, Rejected: 1}},
+		{"tail of one", 1, stavInput, Summary{Compacted: 4, Valid: 2, Rejected: 2}},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			timeline := NewTimeline()
-
+			cfg := DefaultConfig()
+			cfg.HistoryTail = c.tail
+			timeline := NewTimelineWithConfig(cfg)
+			full := NewTimeline()
 			for _, ie := range c.in {
 				timeline.Process(ie)
+				full.Process(ie)
 			}
 
-			if got := timeline.Events(); !reflect.DeepEqual(c.want, got) {
-				t.Errorf("%s, want: %v, got: %v", c.name, c.want, got)
+			all := full.Events()
+			want := all[c.want.Compacted:]
+			if got := timeline.Events(); !reflect.DeepEqual(want, got) {
+				t.Errorf("%s, want: %v, got: %v", c.name, want, got)
+			}
+			if got := timeline.Summary(); got != c.want {
+				t.Errorf("%s, want: %+v, got: %+v", c.name, c.want, got)
+			}
+			if want, got := full.State(), timeline.State(); !reflect.DeepEqual(want, got) {
+				t.Errorf("%s, want: %v, got: %v", c.name, want, got)
 			}
 		})
 	}
@@ -63,6 +301,42 @@ func TestTimeline_Last(t *testing.T) {
 	}
 }
 
+// stateStream returns an initialization Event followed by n-1 card and limit Event.
+func stateStream(n int) []Event {
+	stream := make([]Event, 0, n)
+	stream = append(stream, Event{Account: &Account{ActiveCard: true, AvailableLimit: 100}})
+	for i := 1; i < n; i++ {
+		switch i % 4 {
+		case 0:
+			stream = append(stream, Event{Card: &CardStatus{Active: true}})
+		case 1:
+			stream = append(stream, Event{Limit: &LimitChange{Amount: 10, Operation: LimitIncrease}})
+		case 2:
+			stream = append(stream, Event{Card: &CardStatus{Active: false}})
+		case 3:
+			stream = append(stream, Event{Limit: &LimitChange{Amount: 10, Operation: LimitDecrease}})
+		}
+	}
+
+	return stream
+}
+
+// transactionStream returns an initialization Event followed by n-1 Transaction Event, 20 seconds apart
+// and spread over 10 merchants, so some of them are rejected by the velocity rules.
+func transactionStream(n int) []Event {
+	stream := make([]Event, 0, n)
+	stream = append(stream, Event{Account: &Account{ActiveCard: true, AvailableLimit: n}})
+	for i := 1; i < n; i++ {
+		stream = append(stream, Event{Transaction: &Transaction{
+			Merchant: fmt.Sprintf("merchant-%d", i%10),
+			Amount:   1,
+			Time:     datetime(time.Time(trTime).Add(time.Duration(i) * 20 * time.Second)),
+		}})
+	}
+
+	return stream
+}
+
 var (
 	now = time.Now()
 
@@ -82,7 +356,7 @@ var (
 			},
 			Transaction: nil,
 		},
-		Violations: make([]violation, 0),
+		Violations: make([]Violation, 0),
 	}}
 
 	aaiInput = []Event{
@@ -118,7 +392,7 @@ var (
 				Time:     datetime(now),
 			},
 		},
-		Violations: []violation{
+		Violations: []Violation{
 			accountNotInitialized,
 		}},
 		{
@@ -129,7 +403,7 @@ var (
 				},
 				Transaction: nil,
 			},
-			Violations: make([]violation, 0),
+			Violations: make([]Violation, 0),
 		},
 		{
 			Event: Event{
@@ -139,7 +413,7 @@ var (
 				},
 				Transaction: nil,
 			},
-			Violations: []violation{
+			Violations: []Violation{
 				accountAlreadyInitialized,
 			}},
 	}
@@ -170,7 +444,7 @@ var (
 			},
 			Transaction: nil,
 		},
-		Violations: make([]violation, 0)},
+		Violations: make([]Violation, 0)},
 		{
 			Event: Event{
 				Account: &Account{
@@ -183,7 +457,7 @@ var (
 					Time:     trTime,
 				},
 			},
-			Violations: make([]violation, 0)},
+			Violations: make([]Violation, 0)},
 	}
 
 	aniInput = []Event{
@@ -222,7 +496,7 @@ var (
 					Time:     trTime,
 				},
 			},
-			Violations: []violation{
+			Violations: []Violation{
 				accountNotInitialized,
 			},
 		},
@@ -235,7 +509,7 @@ var (
 					Time:     trTime,
 				},
 			},
-			Violations: []violation{
+			Violations: []Violation{
 				accountNotInitialized,
 			},
 		},
@@ -248,7 +522,7 @@ var (
 					Time:     trTime,
 				},
 			},
-			Violations: []violation{
+			Violations: []Violation{
 				accountNotInitialized,
 			},
 		},
@@ -280,7 +554,7 @@ var (
 				},
 				Transaction: nil,
 			},
-			Violations: make([]violation, 0),
+			Violations: make([]Violation, 0),
 		},
 		{
 			Event: Event{
@@ -294,7 +568,7 @@ var (
 					Time:     trTime,
 				},
 			},
-			Violations: []violation{
+			Violations: []Violation{
 				cardNotActive,
 			},
 		},
@@ -342,7 +616,7 @@ var (
 				},
 				Transaction: nil,
 			},
-			Violations: make([]violation, 0),
+			Violations: make([]Violation, 0),
 		},
 		{
 			Event: Event{
@@ -356,7 +630,7 @@ var (
 					Time:     trTime,
 				},
 			},
-			Violations: []violation{
+			Violations: []Violation{
 				insufficientLimit,
 			},
 		},
@@ -372,7 +646,7 @@ var (
 					Time:     trTime,
 				},
 			},
-			Violations: make([]violation, 0),
+			Violations: make([]Violation, 0),
 		},
 		{
 			Event: Event{
@@ -386,7 +660,7 @@ var (
 					Time:     trTime,
 				},
 			},
-			Violations: []violation{
+			Violations: []Violation{
 				insufficientLimit,
 			},
 		},
@@ -444,7 +718,7 @@ var (
 				},
 				Transaction: nil,
 			},
-			Violations: make([]violation, 0),
+			Violations: make([]Violation, 0),
 		},
 		{
 			Event: Event{
@@ -458,7 +732,7 @@ var (
 					Time:     hfTime,
 				},
 			},
-			Violations: make([]violation, 0),
+			Violations: make([]Violation, 0),
 		},
 		{
 			Event: Event{
@@ -472,7 +746,7 @@ var (
 					Time:     hfTime,
 				},
 			},
-			Violations: make([]violation, 0),
+			Violations: make([]Violation, 0),
 		},
 		{
 			Event: Event{
@@ -486,7 +760,7 @@ var (
 					Time:     hfTime2,
 				},
 			},
-			Violations: make([]violation, 0),
+			Violations: make([]Violation, 0),
 		},
 		{
 			Event: Event{
@@ -500,7 +774,7 @@ var (
 					Time:     hfTime2,
 				},
 			},
-			Violations: []violation{
+			Violations: []Violation{
 				highFrequency,
 			},
 		},
@@ -550,7 +824,7 @@ var (
 				},
 				Transaction: nil,
 			},
-			Violations: make([]violation, 0),
+			Violations: make([]Violation, 0),
 		},
 		{
 			Event: Event{
@@ -564,7 +838,7 @@ var (
 					Time:     dtTime,
 				},
 			},
-			Violations: make([]violation, 0),
+			Violations: make([]Violation, 0),
 		},
 		{
 			Event: Event{
@@ -578,7 +852,7 @@ var (
 					Time:     dtTime,
 				},
 			},
-			Violations: make([]violation, 0),
+			Violations: make([]Violation, 0),
 		},
 		{
 			Event: Event{
@@ -592,7 +866,7 @@ var (
 					Time:     dtTime2,
 				},
 			},
-			Violations: []violation{
+			Violations: []Violation{
 				doubleTransaction,
 			},
 		},
@@ -652,7 +926,7 @@ var (
 				},
 				Transaction: nil,
 			},
-			Violations: make([]violation, 0),
+			Violations: make([]Violation, 0),
 		},
 		{
 			Event: Event{
@@ -666,7 +940,7 @@ var (
 					Time:     stavTime,
 				},
 			},
-			Violations: []violation{
+			Violations: []Violation{
 				insufficientLimit,
 			},
 		},
@@ -682,7 +956,7 @@ var (
 					Time:     stavTime2,
 				},
 			},
-			Violations: []violation{
+			Violations: []Violation{
 				insufficientLimit,
 			},
 		},
@@ -698,7 +972,7 @@ var (
 					Time:     stavTime3,
 				},
 			},
-			Violations: make([]violation, 0),
+			Violations: make([]Violation, 0),
 		},
 		{
 			Event: Event{
@@ -712,7 +986,7 @@ var (
 					Time:     stavTime4,
 				},
 			},
-			Violations: make([]violation, 0),
+			Violations: make([]Violation, 0),
 		},
 	}
 
@@ -770,7 +1044,7 @@ var (
 				},
 				Transaction: nil,
 			},
-			Violations: make([]violation, 0),
+			Violations: make([]Violation, 0),
 		},
 		{
 			Event: Event{
@@ -784,7 +1058,7 @@ var (
 					Time:     stavTime,
 				},
 			},
-			Violations: []violation{
+			Violations: []Violation{
 				insufficientLimit,
 			},
 		},
@@ -800,7 +1074,7 @@ var (
 					Time:     stavTime2,
 				},
 			},
-			Violations: []violation{
+			Violations: []Violation{
 				insufficientLimit,
 			},
 		},
@@ -816,7 +1090,7 @@ var (
 					Time:     stavTime3,
 				},
 			},
-			Violations: make([]violation, 0),
+			Violations: make([]Violation, 0),
 		},
 		{
 			Event: Event{
@@ -830,7 +1104,7 @@ var (
 					Time:     stavTime4,
 				},
 			},
-			Violations: make([]violation, 0),
+			Violations: make([]Violation, 0),
 		},
 	}
 
@@ -842,7 +1116,7 @@ var (
 			},
 			Transaction: nil,
 		},
-		Violations: make([]violation, 0),
+		Violations: make([]Violation, 0),
 	}
 	tlLastEvent = TimelineEvent{
 		Event: Event{
@@ -856,7 +1130,7 @@ var (
 				Time:     datetime(time.Now()),
 			},
 		},
-		Violations: make([]violation, 0),
+		Violations: make([]Violation, 0),
 	}
 	tlw1Event = []TimelineEvent{
 		tlFirstEvent,
@@ -865,4 +1139,1088 @@ var (
 		tlFirstEvent,
 		tlLastEvent,
 	}
-)
\ No newline at end of file
+)
+var (
+	caInput = []Event{
+		{
+			Card: &CardStatus{Active: true},
+		},
+		{
+			Account: &Account{
+				ActiveCard:     false,
+				AvailableLimit: 100,
+			},
+		},
+		{
+			Card: &CardStatus{Active: true},
+		},
+		{
+			Transaction: &Transaction{
+				Merchant: "Kansas City Royals",
+				Amount:   20,
+				Time:     trTime,
+			},
+		},
+	}
+	caOutput = []TimelineEvent{
+		{
+			Event: Event{
+				Account: nil,
+				Card:    &CardStatus{Active: true},
+			},
+			Violations: []Violation{
+				accountNotInitialized,
+			},
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     false,
+					AvailableLimit: 100,
+				},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 100,
+				},
+				Card: &CardStatus{Active: true},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 80,
+				},
+				Transaction: &Transaction{
+					Merchant: "Kansas City Royals",
+					Amount:   20,
+					Time:     trTime,
+				},
+			},
+			Violations: make([]Violation, 0),
+		},
+	}
+
+	cbInput = []Event{
+		{
+			Account: &Account{
+				ActiveCard:     true,
+				AvailableLimit: 100,
+			},
+		},
+		{
+			Card: &CardStatus{Active: false},
+		},
+		{
+			Transaction: &Transaction{
+				Merchant: "Oakland Athletics",
+				Amount:   20,
+				Time:     trTime,
+			},
+		},
+	}
+	cbOutput = []TimelineEvent{
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 100,
+				},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     false,
+					AvailableLimit: 100,
+				},
+				Card: &CardStatus{Active: false},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     false,
+					AvailableLimit: 100,
+				},
+				Transaction: &Transaction{
+					Merchant: "Oakland Athletics",
+					Amount:   20,
+					Time:     trTime,
+				},
+			},
+			Violations: []Violation{
+				cardNotActive,
+			},
+		},
+	}
+
+	caaInput = []Event{
+		{
+			Account: &Account{
+				ActiveCard:     true,
+				AvailableLimit: 100,
+			},
+		},
+		{
+			Card: &CardStatus{Active: true},
+		},
+	}
+	caaOutput = []TimelineEvent{
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 100,
+				},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 100,
+				},
+				Card: &CardStatus{Active: true},
+			},
+			Violations: []Violation{
+				cardAlreadyActive,
+			},
+		},
+	}
+
+	cabInput = []Event{
+		{
+			Account: &Account{
+				ActiveCard:     true,
+				AvailableLimit: 100,
+			},
+		},
+		{
+			Card: &CardStatus{Active: false},
+		},
+		{
+			Card: &CardStatus{Active: false},
+		},
+	}
+	cabOutput = []TimelineEvent{
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 100,
+				},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     false,
+					AvailableLimit: 100,
+				},
+				Card: &CardStatus{Active: false},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     false,
+					AvailableLimit: 100,
+				},
+				Card: &CardStatus{Active: false},
+			},
+			Violations: []Violation{
+				cardAlreadyBlocked,
+			},
+		},
+	}
+)
+
+var (
+	lcInput = []Event{
+		{
+			Limit: &LimitChange{Amount: 50, Operation: LimitIncrease},
+		},
+		{
+			Account: &Account{
+				ActiveCard:     true,
+				AvailableLimit: 100,
+			},
+		},
+		{
+			Limit: &LimitChange{Amount: 50, Operation: LimitIncrease},
+		},
+		{
+			Transaction: &Transaction{
+				Merchant: "Houston Astros",
+				Amount:   120,
+				Time:     trTime,
+			},
+		},
+		{
+			Limit: &LimitChange{Amount: 30, Operation: LimitDecrease},
+		},
+		{
+			Limit: &LimitChange{Amount: 200, Operation: LimitSet},
+		},
+	}
+	lcOutput = []TimelineEvent{
+		{
+			Event: Event{
+				Account: nil,
+				Limit:   &LimitChange{Amount: 50, Operation: LimitIncrease},
+			},
+			Violations: []Violation{
+				accountNotInitialized,
+			},
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 100,
+				},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 150,
+				},
+				Limit: &LimitChange{Amount: 50, Operation: LimitIncrease},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 30,
+				},
+				Transaction: &Transaction{
+					Merchant: "Houston Astros",
+					Amount:   120,
+					Time:     trTime,
+				},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 0,
+				},
+				Limit: &LimitChange{Amount: 30, Operation: LimitDecrease},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 200,
+				},
+				Limit: &LimitChange{Amount: 200, Operation: LimitSet},
+			},
+			Violations: make([]Violation, 0),
+		},
+	}
+
+	nlInput = []Event{
+		{
+			Account: &Account{
+				ActiveCard:     true,
+				AvailableLimit: 100,
+			},
+		},
+		{
+			Limit: &LimitChange{Amount: 101, Operation: LimitDecrease},
+		},
+		{
+			Transaction: &Transaction{
+				Merchant: "Texas Rangers",
+				Amount:   100,
+				Time:     trTime,
+			},
+		},
+	}
+	nlOutput = []TimelineEvent{
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 100,
+				},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 100,
+				},
+				Limit: &LimitChange{Amount: 101, Operation: LimitDecrease},
+			},
+			Violations: []Violation{
+				negativeLimit,
+			},
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 0,
+				},
+				Transaction: &Transaction{
+					Merchant: "Texas Rangers",
+					Amount:   100,
+					Time:     trTime,
+				},
+			},
+			Violations: make([]Violation, 0),
+		},
+	}
+)
+
+var (
+	rrTr = Transaction{
+		ID:       "t1",
+		Merchant: "Colorado Rockies",
+		Amount:   60,
+		Time:     trTime,
+	}
+	rrInput = []Event{
+		{
+			Account: &Account{
+				ActiveCard:     true,
+				AvailableLimit: 100,
+			},
+		},
+		{
+			Transaction: &rrTr,
+		},
+		{
+			Refund: &Refund{TransactionID: "t1", Amount: 15, Time: trTime},
+		},
+		{
+			Refund: &Refund{TransactionID: "t1", Amount: 5, Time: trTime},
+		},
+		{
+			Reversal: &Reversal{TransactionID: "t1", Time: trTime},
+		},
+	}
+	rrOutput = []TimelineEvent{
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 100,
+				},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 40,
+				},
+				Transaction: &rrTr,
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 55,
+				},
+				Refund: &Refund{TransactionID: "t1", Amount: 15, Time: trTime},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 60,
+				},
+				Refund: &Refund{TransactionID: "t1", Amount: 5, Time: trTime},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 100,
+				},
+				Reversal: &Reversal{TransactionID: "t1", Time: trTime},
+			},
+			Violations: make([]Violation, 0),
+		},
+	}
+
+	rvInput = []Event{
+		{
+			Reversal: &Reversal{TransactionID: "t1", Time: trTime},
+		},
+		{
+			Account: &Account{
+				ActiveCard:     true,
+				AvailableLimit: 100,
+			},
+		},
+		{
+			Transaction: &rrTr,
+		},
+		{
+			Refund: &Refund{TransactionID: "t2", Amount: 10, Time: trTime},
+		},
+		{
+			Refund: &Refund{TransactionID: "t1", Amount: 61, Time: trTime},
+		},
+		{
+			Reversal: &Reversal{TransactionID: "t1", Time: trTime},
+		},
+		{
+			Reversal: &Reversal{TransactionID: "t1", Time: trTime},
+		},
+		{
+			Refund: &Refund{TransactionID: "t1", Amount: 1, Time: trTime},
+		},
+	}
+	rvOutput = []TimelineEvent{
+		{
+			Event: Event{
+				Account:  nil,
+				Reversal: &Reversal{TransactionID: "t1", Time: trTime},
+			},
+			Violations: []Violation{
+				accountNotInitialized,
+			},
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 100,
+				},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 40,
+				},
+				Transaction: &rrTr,
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 40,
+				},
+				Refund: &Refund{TransactionID: "t2", Amount: 10, Time: trTime},
+			},
+			Violations: []Violation{
+				unknownTransaction,
+			},
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 40,
+				},
+				Refund: &Refund{TransactionID: "t1", Amount: 61, Time: trTime},
+			},
+			Violations: []Violation{
+				refundExceedsAmount,
+			},
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 100,
+				},
+				Reversal: &Reversal{TransactionID: "t1", Time: trTime},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 100,
+				},
+				Reversal: &Reversal{TransactionID: "t1", Time: trTime},
+			},
+			Violations: []Violation{
+				alreadyReversed,
+			},
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 100,
+				},
+				Refund: &Refund{TransactionID: "t1", Amount: 1, Time: trTime},
+			},
+			Violations: []Violation{
+				alreadyReversed,
+			},
+		},
+	}
+)
+
+var (
+	acAuth = Transaction{
+		ID:       "hotel",
+		Merchant: "Calgary Flames",
+		Amount:   70,
+		Time:     trTime,
+	}
+	acInput = []Event{
+		{
+			Account: &Account{
+				ActiveCard:     true,
+				AvailableLimit: 100,
+			},
+		},
+		{
+			Transaction: &acAuth,
+			Hold:        true,
+		},
+		{
+			Capture: &Capture{AuthorizationID: "fuel", Amount: 50, Time: trTime},
+		},
+		{
+			Capture: &Capture{AuthorizationID: "hotel", Amount: 71, Time: trTime},
+		},
+		{
+			Capture: &Capture{AuthorizationID: "hotel", Amount: 50, Time: trTime},
+		},
+		{
+			Refund: &Refund{TransactionID: "hotel", Amount: 50, Time: trTime},
+		},
+	}
+	acOutput = []TimelineEvent{
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 100,
+				},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 30,
+					HeldLimit:      70,
+				},
+				Transaction: &acAuth,
+				Hold:        true,
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 30,
+					HeldLimit:      70,
+				},
+				Capture: &Capture{AuthorizationID: "fuel", Amount: 50, Time: trTime},
+			},
+			Violations: []Violation{
+				unknownAuthorization,
+			},
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 30,
+					HeldLimit:      70,
+				},
+				Capture: &Capture{AuthorizationID: "hotel", Amount: 71, Time: trTime},
+			},
+			Violations: []Violation{
+				captureExceedsAmount,
+			},
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 50,
+				},
+				Capture: &Capture{AuthorizationID: "hotel", Amount: 50, Time: trTime},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 100,
+				},
+				Refund: &Refund{TransactionID: "hotel", Amount: 50, Time: trTime},
+			},
+			Violations: make([]Violation, 0),
+		},
+	}
+
+	aeTime  = datetime(time.Time(trTime).Add(7*24*time.Hour + time.Second))
+	aeInput = []Event{
+		{
+			Account: &Account{
+				ActiveCard:     true,
+				AvailableLimit: 100,
+			},
+		},
+		{
+			Transaction: &acAuth,
+			Hold:        true,
+		},
+		{
+			Transaction: &Transaction{
+				Merchant: "Edmonton Oilers",
+				Amount:   100,
+				Time:     aeTime,
+			},
+		},
+		{
+			Capture: &Capture{AuthorizationID: "hotel", Amount: 70, Time: aeTime},
+		},
+	}
+	aeOutput = []TimelineEvent{
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 100,
+				},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 30,
+					HeldLimit:      70,
+				},
+				Transaction: &acAuth,
+				Hold:        true,
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 100,
+				},
+				Expired: &acAuth,
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 0,
+				},
+				Transaction: &Transaction{
+					Merchant: "Edmonton Oilers",
+					Amount:   100,
+					Time:     aeTime,
+				},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 0,
+				},
+				Capture: &Capture{AuthorizationID: "hotel", Amount: 70, Time: aeTime},
+			},
+			Violations: []Violation{
+				unknownAuthorization,
+			},
+		},
+	}
+)
+
+var (
+	irTr = Transaction{
+		ID:       "t1",
+		Merchant: "Vancouver Canucks",
+		Amount:   30,
+		Time:     trTime,
+	}
+	irRejected = Transaction{
+		ID:       "t2",
+		Merchant: "Minnesota Wild",
+		Amount:   80,
+		Time:     trTime,
+	}
+	irInput = []Event{
+		{
+			Account: &Account{
+				ActiveCard:     true,
+				AvailableLimit: 100,
+			},
+		},
+		{
+			Transaction: &irTr,
+		},
+		{
+			Transaction: &irTr,
+		},
+		{
+			Transaction: &irRejected,
+		},
+		{
+			Transaction: &irRejected,
+		},
+	}
+	irOutput = []TimelineEvent{
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 100,
+				},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 70,
+				},
+				Transaction: &irTr,
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 70,
+				},
+				Transaction: &irTr,
+			},
+			Violations: make([]Violation, 0),
+			Replay:     true,
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 70,
+				},
+				Transaction: &irRejected,
+			},
+			Violations: []Violation{
+				insufficientLimit,
+			},
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 70,
+				},
+				Transaction: &irRejected,
+			},
+			Violations: []Violation{
+				insufficientLimit,
+			},
+			Replay: true,
+		},
+	}
+
+	icTr = Transaction{
+		ID:       "t1",
+		Merchant: "Vancouver Canucks",
+		Amount:   31,
+		Time:     trTime,
+	}
+	icInput = []Event{
+		{
+			Account: &Account{
+				ActiveCard:     true,
+				AvailableLimit: 100,
+			},
+		},
+		{
+			Transaction: &irTr,
+		},
+		{
+			Transaction: &icTr,
+		},
+		{
+			Transaction: &irTr,
+			Hold:        true,
+		},
+	}
+	icOutput = []TimelineEvent{
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 100,
+				},
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 70,
+				},
+				Transaction: &irTr,
+			},
+			Violations: make([]Violation, 0),
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 70,
+				},
+				Transaction: &icTr,
+			},
+			Violations: []Violation{
+				transactionIDConflict,
+			},
+		},
+		{
+			Event: Event{
+				Account: &Account{
+					ActiveCard:     true,
+					AvailableLimit: 70,
+				},
+				Transaction: &irTr,
+				Hold:        true,
+			},
+			Violations: []Violation{
+				transactionIDConflict,
+			},
+		},
+	}
+)
+
+func TestTimeline_Process_LateReject(t *testing.T) {
+	cases := []struct {
+		name     string
+		lateness time.Duration
+		in       []Event
+		want     [][]string
+	}{
+		{"in order", 30 * time.Second, []Event{lateAccount, lateTransaction("A", 0), lateTransaction("B", time.Minute)},
+			[][]string{{"account"}, {"A"}, {"B"}}},
+		{"within lateness", 30 * time.Second, []Event{lateAccount, lateTransaction("A", 2*time.Minute), lateTransaction("B", 100*time.Second)},
+			[][]string{{"account"}, {"A"}, {"B"}}},
+		{"beyond lateness", 30 * time.Second, []Event{lateAccount, lateTransaction("A", 2*time.Minute), lateTransaction("B", 0), lateTransaction("C", time.Minute)},
+			[][]string{{"account"}, {"A"}, {"B:late-event"}, {"C:late-event"}}},
+		{"late replay", 30 * time.Second, []Event{lateAccount, withID(lateTransaction("A", 0), "t1"), lateTransaction("B", 3*time.Minute), withID(lateTransaction("A", 0), "t1")},
+			[][]string{{"account"}, {"A#t1"}, {"B"}, {"A#t1:replay"}}},
+		{"late refund", 30 * time.Second, []Event{lateAccount, withID(lateTransaction("A", 0), "t1"), lateTransaction("B", 3*time.Minute),
+			{Refund: &Refund{TransactionID: "t1", Amount: 5, Time: datetime(time.Time(trTime).Add(time.Minute))}}},
+			[][]string{{"account"}, {"A#t1"}, {"B"}, {"refund:late-event"}}},
+		{"without datetime", 30 * time.Second, []Event{lateAccount, lateTransaction("A", 3*time.Minute), {Card: &CardStatus{Active: false}}},
+			[][]string{{"account"}, {"A"}, {"card"}}},
+		{"lateness beyond the window", 10 * time.Minute, []Event{lateAccount, lateTransaction("A", 0), lateTransaction("B", 30*time.Second),
+			lateTransaction("C", time.Minute), lateTransaction("D", 9*time.Minute), lateTransaction("A", 90*time.Second)},
+			[][]string{{"account"}, {"A"}, {"B"}, {"C"}, {"D"}, {"A:high-frequency-small-interval:double-Transaction"}}},
+		{"later transactions beyond the window", 5 * time.Minute, []Event{lateAccount, lateTransaction("A", 3*time.Minute),
+			lateTransaction("B", 4*time.Minute), lateTransaction("C", 5*time.Minute), lateTransaction("A", 0)},
+			[][]string{{"account"}, {"A"}, {"B"}, {"C"}, {"A"}}},
+		{"later transaction of the same merchant", 90 * time.Second, []Event{lateAccount, lateTransaction("A", 5*time.Minute),
+			lateTransaction("A", 4*time.Minute)},
+			[][]string{{"account"}, {"A"}, {"A:double-Transaction"}}},
+		{"later transactions over the limit", 2 * time.Minute, []Event{lateAccount, lateTransaction("B", time.Minute),
+			lateTransaction("C", 90*time.Second), lateTransaction("D", 2*time.Minute), lateTransaction("A", 30*time.Second)},
+			[][]string{{"account"}, {"B"}, {"C"}, {"D"}, {"A:high-frequency-small-interval"}}},
+		{"later transactions within the limit", 2 * time.Minute, []Event{lateAccount, lateTransaction("B", time.Minute),
+			lateTransaction("C", 150*time.Second), lateTransaction("A", 30*time.Second), lateTransaction("D", 3*time.Minute)},
+			[][]string{{"account"}, {"B"}, {"C"}, {"A"}, {"D"}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.LatePolicy, cfg.Lateness = LateReject, duration(c.lateness)
+			timeline := NewTimelineWithConfig(cfg)
+
+			got := make([][]string, 0, len(c.in))
+			for _, ie := range c.in {
+				timeline.Process(ie)
+				got = append(got, decisions(timeline.Decided()))
+			}
+			if !reflect.DeepEqual(c.want, got) {
+				t.Errorf("%s, want: %v, got: %v", c.name, c.want, got)
+			}
+		})
+	}
+}
+
+func TestTimeline_Process_LateRejectExplain(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.LatePolicy, cfg.Lateness, cfg.Explain = LateReject, duration(90*time.Second), true
+	timeline := NewTimelineWithConfig(cfg)
+	for _, ie := range []Event{lateAccount, lateTransaction("A", 5*time.Minute), lateTransaction("A", 4*time.Minute)} {
+		timeline.Process(ie)
+	}
+
+	want := []Transaction{*lateTransaction("A", 4*time.Minute).Transaction, *lateTransaction("A", 5*time.Minute).Transaction}
+	got := timeline.Last().Explanations
+	if len(got) != 1 || got[0].Violation != doubleTransaction || !reflect.DeepEqual(want, got[0].Transactions) {
+		t.Errorf("want: %v, got: %+v", want, got)
+	}
+}
+
+func TestTimeline_Process_LateReorder(t *testing.T) {
+	cases := []struct {
+		name     string
+		lateness time.Duration
+		in       []Event
+		want     [][]string
+	}{
+		{"in order", time.Minute, []Event{lateAccount, lateTransaction("A", 0), lateTransaction("B", 2*time.Minute), lateTransaction("C", 4*time.Minute)},
+			[][]string{{"account"}, {}, {"A"}, {"B"}, {"C"}}},
+		{"without lateness", 0, []Event{lateAccount, lateTransaction("A", time.Minute), lateTransaction("B", 0)},
+			[][]string{{"account"}, {"A"}, {"B:late-event"}, {}}},
+		{"within watermark", time.Minute, []Event{lateAccount, lateTransaction("A", 2*time.Minute), lateTransaction("B", 100*time.Second),
+			lateTransaction("C", 4*time.Minute)},
+			[][]string{{"account"}, {}, {}, {"B", "A"}, {"C"}}},
+		{"beyond watermark", time.Minute, []Event{lateAccount, lateTransaction("A", 2*time.Minute), lateTransaction("B", 0)},
+			[][]string{{"account"}, {}, {"B:late-event"}, {"A"}}},
+		{"after released", time.Minute, []Event{lateAccount, lateTransaction("A", 0), lateTransaction("B", 3*time.Minute), lateTransaction("C", 30*time.Second)},
+			[][]string{{"account"}, {}, {"A"}, {"C:late-event"}, {"B"}}},
+		{"retry after released", time.Minute, []Event{lateAccount, withID(lateTransaction("A", 0), "a"), lateTransaction("B", 3*time.Minute),
+			withID(lateTransaction("A", 0), "a")},
+			[][]string{{"account"}, {}, {"A#a"}, {"A#a:replay"}, {"B"}}},
+		{"decided in time order", 5 * time.Minute, outOfOrder, [][]string{{"account"}, {}, {}, {"M#earlier", "M#later:double-Transaction"}}},
+		{"without datetime", time.Minute, []Event{lateAccount, lateTransaction("A", 0), {Card: &CardStatus{Active: false}}, lateTransaction("B", 0)},
+			[][]string{{"account"}, {}, {"A", "card"}, {"B:card-not-active"}, {}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.LatePolicy, cfg.Lateness = LateReorder, duration(c.lateness)
+			timeline := NewTimelineWithConfig(cfg)
+
+			got := make([][]string, 0, len(c.in)+1)
+			for _, ie := range c.in {
+				timeline.Process(ie)
+				got = append(got, decisions(timeline.Decided()))
+			}
+			timeline.Flush()
+			got = append(got, decisions(timeline.Decided()))
+			if !reflect.DeepEqual(c.want, got) {
+				t.Errorf("%s, want: %v, got: %v", c.name, c.want, got)
+			}
+		})
+	}
+}
+
+// TestTimeline_Process_LateAccept shows why late Event need a policy: the later Transaction was approved before the
+// earlier one arrived, and the window of the earlier one ends at its datetime, so neither violates double-Transaction.
+func TestTimeline_Process_LateAccept(t *testing.T) {
+	timeline := NewTimeline()
+	got := make([][]string, 0)
+	for _, ie := range outOfOrder {
+		timeline.Process(ie)
+		got = append(got, decisions(timeline.Decided()))
+	}
+
+	want := [][]string{{"account"}, {"M#later"}, {"M#earlier"}}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
+
+var (
+	lateAccount = Event{Account: &Account{ActiveCard: true, AvailableLimit: 100}}
+	// outOfOrder has two Transaction of the same Merchant within DuplicateWindow, the later one arrives first.
+	outOfOrder = []Event{lateAccount, withID(lateTransaction("M", 100*time.Second), "later"), withID(lateTransaction("M", 0), "earlier")}
+)
+
+// lateTransaction returns a Transaction Event of the Merchant at the given offset from trTime.
+func lateTransaction(merchant string, offset time.Duration) Event {
+	return Event{Transaction: &Transaction{Merchant: merchant, Amount: 10, Time: datetime(time.Time(trTime).Add(offset))}}
+}
+
+// withID returns the Transaction Event with the given ID.
+func withID(ie Event, id string) Event {
+	tr := *ie.Transaction
+	tr.ID = id
+	ie.Transaction = &tr
+
+	return ie
+}
+
+// decisions describes each TimelineEvent by its Merchant and ID, or its kind when it is not a Transaction, followed by
+// its violations or replay.
+func decisions(tes []TimelineEvent) []string {
+	ds := make([]string, 0, len(tes))
+	for _, te := range tes {
+		var d string
+		switch {
+		case te.Transaction != nil && te.Transaction.ID != "":
+			d = te.Merchant + "#" + te.Transaction.ID
+		case te.Transaction != nil:
+			d = te.Merchant
+		case te.Card != nil:
+			d = "card"
+		case te.Refund != nil:
+			d = "refund"
+		default:
+			d = "account"
+		}
+		for _, v := range te.Violations {
+			d += ":" + string(v)
+		}
+		if te.Replay {
+			d += ":replay"
+		}
+		ds = append(ds, d)
+	}
+
+	return ds
+}
@@ -0,0 +1,252 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// SyncAlways flushes each record to stable storage before Append returns. It is the safest and slowest policy.
+	SyncAlways SyncPolicy = iota
+	// SyncBatch flushes the records to stable storage every walBatch records and on Close. A crash of the
+	// process loses nothing, but a crash of the machine could lose the last batch.
+	SyncBatch
+	// SyncNever leaves flushing to the operating system. A crash of the process loses nothing, but a crash of the
+	// machine could lose any record not yet flushed.
+	SyncNever
+)
+
+// walBatch is the number of records written between two flushes when the SyncPolicy is SyncBatch.
+const walBatch = 64
+
+// walFlushRecord is the record appended where the Event buffered by the late policy were flushed, see AppendFlush.
+// It is never an Event.
+const walFlushRecord = `{"wal-flush":true}`
+
+// ErrCorruptWAL is returned when a record of the WAL, other than the last one, is not a valid Event.
+var ErrCorruptWAL = errors.New("corrupt write-ahead log")
+
+type (
+	// SyncPolicy defines when the records of a WAL are flushed to stable storage.
+	SyncPolicy int
+
+	// WAL is an append-only write-ahead log of the input Event, one JSON line per record.
+	// Events are appended before their results are emitted, so replaying the log into fresh Timeline rebuilds the
+	// state where processing stopped. It is NOT thread safe.
+	// Each record has a sequence number that keeps growing across Reset, so a Snapshot could tell which records it
+	// already has, see Snapshot.WALSequence. A log that was reset starts with a walHeader record, and the flushes of
+	// the late policy are records as well, see AppendFlush.
+	WAL struct {
+		// file is the log file, opened for appending.
+		file *os.File
+		// path is the path of the log file.
+		path string
+		// policy defines when records are flushed to stable storage.
+		policy SyncPolicy
+		// pending is the number of records written since the last flush.
+		pending int
+		// next is the sequence number of the next record.
+		next uint64
+	}
+	// Replayer receives the records of a WAL when it is opened, see OpenWAL.
+	Replayer interface {
+		// Replay receives each logged Event, in order.
+		Replay(ie Event)
+		// ReplayFlush is called where the Event buffered by the late policy were flushed, see WAL.AppendFlush.
+		ReplayFlush()
+	}
+	// ReplayFunc is an adapter to allow the use of ordinary functions as Replayer. Flushes are ignored.
+	ReplayFunc func(ie Event)
+
+	// walHeader is the first record of a log that was reset. It is never an Event.
+	walHeader struct {
+		// Sequence is the sequence number of the first record after the header.
+		Sequence *uint64 `json:"wal-sequence"`
+	}
+)
+
+// syncPolicies maps the names of the SyncPolicy to their values.
+var syncPolicies = map[string]SyncPolicy{"always": SyncAlways, "batch": SyncBatch, "never": SyncNever}
+
+// ParseSyncPolicy returns the SyncPolicy of the given name: always, batch or never.
+func ParseSyncPolicy(name string) (SyncPolicy, error) {
+	p, ok := syncPolicies[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown sync policy %q, want always, batch or never", name)
+	}
+
+	return p, nil
+}
+
+// String returns the name of the SyncPolicy.
+func (p SyncPolicy) String() string {
+	for name, sp := range syncPolicies {
+		if sp == p {
+			return name
+		}
+	}
+
+	return fmt.Sprintf("SyncPolicy(%d)", int(p))
+}
+
+// OpenWAL opens the log file of the given path, creating it when needed, and replays each Event and flush already
+// logged into the Replayer, in order. Records with a sequence number before from are skipped, since they are already
+// in the restored Snapshot (see Snapshot.WALSequence). When all records are before from, the log is reset to from.
+// A last record without line break was torn by a crash while it was written, so it is discarded.
+// It returns an error wrapping ErrCorruptWAL when any other record is not a valid Event.
+func OpenWAL(path string, policy SyncPolicy, from uint64, replay Replayer) (*WAL, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	size, next, err := replayWAL(file, from, replay)
+	if err == nil {
+		err = file.Truncate(size)
+	}
+	if err == nil {
+		_, err = file.Seek(size, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	l := &WAL{file: file, path: path, policy: policy, next: next}
+	if next < from {
+		l.next = from
+		if err := l.Reset(); err != nil {
+			l.file.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	return l, nil
+}
+
+// replayWAL replays each complete record of r with a sequence number at or after from. It returns the size of the
+// complete records and the sequence number of the next record.
+func replayWAL(r io.Reader, from uint64, replay Replayer) (int64, uint64, error) {
+	var size int64
+	var seq uint64
+	reader := bufio.NewReader(r)
+	for record := 1; ; record++ {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return size, seq, nil
+		}
+		if err != nil {
+			return size, seq, err
+		}
+		input := bytes.TrimSuffix(line, []byte("\n"))
+		size += int64(len(line))
+
+		var h walHeader
+		if record == 1 && json.Unmarshal(input, &h) == nil && h.Sequence != nil {
+			seq = *h.Sequence
+			continue
+		}
+		if string(input) == walFlushRecord {
+			if seq >= from {
+				replay.ReplayFlush()
+			}
+			seq++
+			continue
+		}
+		ie, err := Parse(string(input))
+		if err != nil {
+			return size - int64(len(line)), seq, fmt.Errorf("%w: record %d: %v", ErrCorruptWAL, record, err)
+		}
+		if seq >= from {
+			replay.Replay(ie)
+		}
+		seq++
+	}
+}
+
+// Append writes the input of an Event as a new record and flushes it according to the SyncPolicy.
+func (l *WAL) Append(input string) error {
+	if _, err := l.file.WriteString(input + "\n"); err != nil {
+		return err
+	}
+
+	l.next++
+	l.pending++
+	if l.policy == SyncAlways || (l.policy == SyncBatch && l.pending >= walBatch) {
+		return l.sync()
+	}
+
+	return nil
+}
+
+// AppendFlush writes a record telling that the Event buffered by the late policy were flushed, e.g. when the input
+// ended, so replaying the log flushes them at the same point instead of leaving them buffered.
+func (l *WAL) AppendFlush() error {
+	return l.Append(walFlushRecord)
+}
+
+// Replay calls f(ie).
+func (f ReplayFunc) Replay(ie Event) {
+	f(ie)
+}
+
+// ReplayFlush implements Replayer interface.
+func (ReplayFunc) ReplayFlush() {}
+
+// Close flushes the pending records, unless the SyncPolicy is SyncNever, and closes the log file.
+func (l *WAL) Close() error {
+	var err error
+	if l.policy != SyncNever && l.pending > 0 {
+		err = l.sync()
+	}
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// sync flushes the records written so far to stable storage.
+func (l *WAL) sync() error {
+	l.pending = 0
+
+	return l.file.Sync()
+}
+
+// Sequence returns the sequence number of the next record, i.e. how many records were ever appended.
+func (l *WAL) Sequence() uint64 {
+	return l.next
+}
+
+// Reset discards all records, e.g. once a Snapshot has the state they rebuild. The sequence numbers keep growing.
+// The log is replaced by a new one with a walHeader, written aside and renamed over it, so a crash leaves either the
+// old log or the new one.
+func (l *WAL) Reset() error {
+	file, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".*")
+	if err != nil {
+		return err
+	}
+	header, _ := json.Marshal(walHeader{Sequence: &l.next})
+	if _, err = file.Write(append(header, '\n')); err == nil {
+		err = file.Sync()
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), l.path)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+
+	l.file.Close()
+	l.file, l.pending = file, 0
+
+	return nil
+}
@@ -0,0 +1,365 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestOpenWAL(t *testing.T) {
+	const (
+		account     = `{"account":{"account-id":"alice","active-card":true,"available-limit":100}}`
+		transaction = `{"transaction":{"account-id":"alice","merchant":"Vegas Golden Knights","amount":20,"time":"2019-02-13T11:00:00.000Z"}}`
+		header      = `{"wal-sequence":5}`
+	)
+	cases := []struct {
+		name    string
+		content string
+		from    uint64
+		want    int
+		size    int
+		next    uint64
+		err     error
+	}{
+		{"new log", "", 0, 0, 0, 0, nil},
+		{"new log after a snapshot", "", 3, 0, len(`{"wal-sequence":3}` + "\n"), 3, nil},
+		{"complete records", account + "\n" + transaction + "\n", 0, 2, len(account + transaction + "\n\n"), 2, nil},
+		{"reset log", header + "\n" + account + "\n" + transaction + "\n", 0, 2, len(header + account + transaction + "\n\n\n"), 7, nil},
+		{"records in the snapshot", header + "\n" + account + "\n" + transaction + "\n", 6, 1, len(header + account + transaction + "\n\n\n"), 7, nil},
+		{"flush record", account + "\n" + walFlushRecord + "\n" + transaction + "\n", 0, 2, len(account + walFlushRecord + transaction + "\n\n\n"), 3, nil},
+		{"flush record in the snapshot", header + "\n" + walFlushRecord + "\n" + transaction + "\n", 6, 1,
+			len(header + walFlushRecord + transaction + "\n\n\n"), 7, nil},
+		{"torn record", account + "\n" + transaction[:20], 0, 1, len(account + "\n"), 1, nil},
+		{"corrupt record", account + "\n" + transaction[:20] + "\n" + transaction + "\n", 0, 0, 0, 0, ErrCorruptWAL},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "wal")
+			if c.content != "" {
+				if err := os.WriteFile(path, []byte(c.content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var replayed []Event
+			l, err := OpenWAL(path, SyncAlways, c.from, ReplayFunc(func(ie Event) { replayed = append(replayed, ie) }))
+			if !errors.Is(err, c.err) {
+				t.Fatalf("%s, want: %v, got: %v", c.name, c.err, err)
+			}
+			if err != nil {
+				return
+			}
+			defer l.Close()
+
+			if got := len(replayed); got != c.want {
+				t.Errorf("%s, want: %d events, got: %d", c.name, c.want, got)
+			}
+			if info, _ := os.Stat(path); info.Size() != int64(c.size) {
+				t.Errorf("%s, want: %d bytes, got: %d", c.name, c.size, info.Size())
+			}
+			if got := l.Sequence(); got != c.next {
+				t.Errorf("%s, want: sequence %d, got: %d", c.name, c.next, got)
+			}
+		})
+	}
+}
+
+func TestWAL_Append(t *testing.T) {
+	for _, policy := range []SyncPolicy{SyncAlways, SyncBatch, SyncNever} {
+		t.Run(policy.String(), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "wal")
+			in := strings.Split(strings.TrimSuffix(lineStream(4, 200), "\n"), "\n")
+
+			l, err := OpenWAL(path, policy, 0, ReplayFunc(func(Event) { t.Error("want nothing to replay") }))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []Event
+			for _, input := range in {
+				if ie, err := Parse(input); err == nil {
+					want = append(want, ie)
+					if err := l.Append(input); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			if err := l.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []Event
+			l, err = OpenWAL(path, policy, 0, ReplayFunc(func(ie Event) { got = append(got, ie) }))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer l.Close()
+			if !reflect.DeepEqual(want, got) {
+				t.Errorf("%s, replayed Event differ from the appended ones", policy)
+			}
+		})
+	}
+}
+
+func TestWAL_Reset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal")
+	in := strings.Split(strings.TrimSuffix(lineStream(1, 4), "\n"), "\n")
+
+	l, err := OpenWAL(path, SyncAlways, 0, ReplayFunc(func(Event) {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range in[:3] {
+		if err := l.Append(input); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Append(in[3]); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []Event
+	l, err = OpenWAL(path, SyncAlways, 0, ReplayFunc(func(ie Event) { got = append(got, ie) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if want, _ := Parse(in[3]); !reflect.DeepEqual([]Event{want}, got) {
+		t.Errorf("want: %v, got: %v", []Event{want}, got)
+	}
+	if got := l.Sequence(); got != 4 {
+		t.Errorf("want: sequence %d, got: %d", 4, got)
+	}
+}
+
+func TestParseSyncPolicy(t *testing.T) {
+	cases := []struct {
+		in   string
+		want SyncPolicy
+		err  bool
+	}{
+		{"always", SyncAlways, false},
+		{"batch", SyncBatch, false},
+		{"never", SyncNever, false},
+		{"sometimes", 0, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.in, func(t *testing.T) {
+			got, err := ParseSyncPolicy(c.in)
+			if (err != nil) != c.err || got != c.want {
+				t.Errorf("%s, want: %v, got: %v, %v", c.in, c.want, got, err)
+			}
+			if err == nil && got.String() != c.in {
+				t.Errorf("want: %s, got: %s", c.in, got)
+			}
+		})
+	}
+}
+
+// TestPipeline_Run_Recovery stops the Pipeline mid-stream, as a crash would, and checks that a new Pipeline resumes
+// from the WAL exactly where the previous one stopped.
+func TestPipeline_Run_Recovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal")
+	lines := strings.SplitAfter(lineStream(8, 1000), "\n")
+	head, tail := strings.Join(lines[:400], ""), strings.Join(lines[400:], "")
+	want := sequential(head + tail)
+
+	var out bytes.Buffer
+	for _, in := range []string{head, tail} {
+		pipeline := NewPipeline(4, NewTimeline)
+		l, err := OpenWAL(path, SyncBatch, 0, pipeline)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pipeline.Log(l)
+		if err := pipeline.Run(strings.NewReader(in), &out); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sameResults(t, want, out.String())
+}
+
+// TestPipeline_Run_SnapshotCrash stops the process after the Snapshot was saved but before the WAL was reset, and
+// checks that the restored Pipeline does not apply the Event of the WAL a second time.
+func TestPipeline_Run_SnapshotCrash(t *testing.T) {
+	dir := t.TempDir()
+	walPath, snapshotPath := filepath.Join(dir, "wal"), filepath.Join(dir, "snapshot")
+	lines := strings.SplitAfter(lineStream(8, 1000), "\n")
+	head, tail := strings.Join(lines[:400], ""), strings.Join(lines[400:], "")
+	want := sequential(head + tail)
+
+	var out bytes.Buffer
+	pipeline := NewPipeline(4, NewTimeline)
+	l, err := OpenWAL(walPath, SyncBatch, 0, pipeline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeline.Log(l)
+	if err := pipeline.Run(strings.NewReader(head), &out); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveSnapshot(snapshotPath, pipeline.Snapshot(DefaultConfig())); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := LoadSnapshot(snapshotPath, DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeline = NewPipeline(4, NewTimeline)
+	pipeline.Restore(snapshot)
+	l, err = OpenWAL(walPath, SyncBatch, snapshot.WALSequence, ReplayFunc(func(Event) { t.Error("want nothing to replay") }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	pipeline.Log(l)
+	if err := pipeline.Run(strings.NewReader(tail), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	sameResults(t, want, out.String())
+}
+
+// TestPipeline_Run_RecoveryLateReorder checks that the Event buffered by LateReorder at the end of a run, which were
+// written when its input ended, are not written again by the next run after they are replayed from the WAL.
+func TestPipeline_Run_RecoveryLateReorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal")
+	cfg := DefaultConfig()
+	cfg.LatePolicy, cfg.Lateness = LateReorder, duration(5*time.Minute)
+	runs := []struct {
+		in, want string
+	}{
+		{`{"account":{"active-card":true,"available-limit":100}}
+{"transaction":{"merchant":"Ottawa Senators","amount":10,"time":"2019-02-13T10:00:00.000Z"}}
+`, `{"Account":{"active-card":true,"available-limit":100},"violations":[]}
+{"Account":{"active-card":true,"available-limit":90},"violations":[]}
+`},
+		{`{"transaction":{"merchant":"Calgary Flames","amount":10,"time":"2019-02-13T10:01:00.000Z"}}
+`, `{"Account":{"active-card":true,"available-limit":80},"violations":[]}
+`},
+	}
+
+	for i, r := range runs {
+		pipeline := NewPipeline(2, func() Timeline { return NewTimelineWithConfig(cfg) })
+		l, err := OpenWAL(path, SyncBatch, 0, pipeline)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pipeline.Log(l)
+		var out bytes.Buffer
+		if err := pipeline.Run(strings.NewReader(r.in), &out); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := out.String(); got != r.want {
+			t.Errorf("run %d, want:\n%s\ngot:\n%s", i+1, r.want, got)
+		}
+	}
+}
+
+// TestPipeline_Run_CrashLateReorder stops the Pipeline before the Event buffered by LateReorder are flushed, as a
+// crash would, and checks that the next run writes them, since the crashed one never did, and that a later run does
+// not write them again.
+func TestPipeline_Run_CrashLateReorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal")
+	cfg := DefaultConfig()
+	cfg.LatePolicy, cfg.Lateness = LateReorder, duration(5*time.Minute)
+	crash := errors.New("crash")
+	runs := []struct {
+		in    string
+		crash bool
+		want  string
+	}{
+		{`{"account":{"active-card":true,"available-limit":100}}
+{"transaction":{"merchant":"Ottawa Senators","amount":10,"time":"2019-02-13T10:00:00.000Z"}}
+`, true, `{"Account":{"active-card":true,"available-limit":100},"violations":[]}
+`},
+		{`{"transaction":{"merchant":"Calgary Flames","amount":10,"time":"2019-02-13T10:01:00.000Z"}}
+`, false, `{"Account":{"active-card":true,"available-limit":90},"violations":[]}
+{"Account":{"active-card":true,"available-limit":80},"violations":[]}
+`},
+		{``, false, ``},
+	}
+
+	for i, r := range runs {
+		pipeline := NewPipeline(2, func() Timeline { return NewTimelineWithConfig(cfg) })
+		l, err := OpenWAL(path, SyncBatch, 0, pipeline)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pipeline.Log(l)
+		var in io.Reader = strings.NewReader(r.in)
+		if r.crash {
+			in = io.MultiReader(in, iotest.ErrReader(crash))
+		}
+		var out bytes.Buffer
+		if err := pipeline.Run(in, &out); r.crash != errors.Is(err, crash) {
+			t.Fatalf("run %d, want crash: %v, got: %v", i+1, r.crash, err)
+		}
+		if err := l.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := out.String(); got != r.want {
+			t.Errorf("run %d, want:\n%s\ngot:\n%s", i+1, r.want, got)
+		}
+	}
+}
+
+func TestPipeline_Run_LogError(t *testing.T) {
+	pipeline := NewPipeline(2, NewTimeline)
+	l, err := OpenWAL(filepath.Join(t.TempDir(), "wal"), SyncNever, 0, pipeline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+	pipeline.Log(l)
+
+	var out bytes.Buffer
+	if err := pipeline.Run(strings.NewReader(lineStream(2, 10)), &out); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("want: %v, got: %v", os.ErrClosed, err)
+	}
+	if got := out.String(); got != "" {
+		t.Errorf("want nothing written, got: %q", got)
+	}
+}
+
+// sameResults compares the output lines of two runs, except the rejections: their line numbers restart with each input.
+func sameResults(t *testing.T, want, got string) {
+	t.Helper()
+	gotLines, wantLines := strings.Split(got, "\n"), strings.Split(want, "\n")
+	if len(gotLines) != len(wantLines) {
+		t.Fatalf("want: %d lines, got: %d", len(wantLines), len(gotLines))
+	}
+	for i := range wantLines {
+		if strings.HasPrefix(wantLines[i], `{"line"`) {
+			continue
+		}
+		if gotLines[i] != wantLines[i] {
+			t.Fatalf("line %d, want: %s, got: %s", i+1, wantLines[i], gotLines[i])
+		}
+	}
+}
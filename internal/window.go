@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"sort"
+	"time"
+)
+
+type (
+	// window is a deque of valid Transaction ordered by datetime, oldest first.
+	// Transaction are pushed at the back and evicted from the front, so it only keeps the most recent ones.
+	window struct {
+		// entries has the Transaction of the window starting at head.
+		entries []Transaction
+		// head is the index of the oldest Transaction in entries.
+		head int
+	}
+)
+
+// push puts the Transaction into the window keeping the datetime order.
+// Transaction arriving in order are appended in O(1).
+func (w *window) push(tr Transaction) {
+	at := time.Time(tr.Time)
+	i := len(w.entries)
+	for i > w.head && time.Time(w.entries[i-1].Time).After(at) {
+		i--
+	}
+
+	w.entries = append(w.entries, Transaction{})
+	copy(w.entries[i+1:], w.entries[i:])
+	w.entries[i] = tr
+}
+
+// evict removes all Transaction that happened before the given datetime and returns them, oldest first.
+// The returned slice is only valid until the next push.
+func (w *window) evict(before time.Time) []Transaction {
+	start := w.head
+	for w.head < len(w.entries) && time.Time(w.entries[w.head].Time).Before(before) {
+		w.head++
+	}
+	evicted := w.entries[start:w.head]
+
+	if w.head > len(w.entries)/2 {
+		w.entries = append(make([]Transaction, 0, len(w.entries)-w.head), w.entries[w.head:]...)
+		w.head = 0
+	}
+
+	return evicted
+}
+
+// since returns the Transaction that happened at or after the given datetime, oldest first.
+// The returned slice must not be modified.
+func (w *window) since(t time.Time) []Transaction {
+	live := w.entries[w.head:]
+	i := sort.Search(len(live), func(i int) bool {
+		return !time.Time(live[i].Time).Before(t)
+	})
+
+	return live[i:]
+}
+
+// between returns the Transaction that happened at or after since and at or before until, oldest first.
+// The returned slice must not be modified.
+func (w *window) between(since, until time.Time) []Transaction {
+	live := w.since(since)
+	i := sort.Search(len(live), func(i int) bool {
+		return time.Time(live[i].Time).After(until)
+	})
+
+	return live[:i]
+}
+
+// latest returns the datetime of the most recent Transaction. It is zero when the window is empty.
+func (w *window) latest() time.Time {
+	if w.len() == 0 {
+		return time.Time{}
+	}
+
+	return time.Time(w.entries[len(w.entries)-1].Time)
+}
+
+// len returns how many Transaction are in the window.
+func (w *window) len() int {
+	return len(w.entries) - w.head
+}
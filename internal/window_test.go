@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWindow_Push(t *testing.T) {
+	cases := []struct {
+		name string
+		in   []int
+		want []int
+	}{
+		{"in order", []int{0, 1, 2, 3}, []int{0, 1, 2, 3}},
+		{"out of order", []int{0, 3, 1, 2}, []int{0, 1, 2, 3}},
+		{"same datetime keeps arrival order", []int{1, 0, 1, 1}, []int{0, 1, 1, 1}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := &window{}
+			for i, m := range c.in {
+				w.push(windowTr(i, m))
+			}
+			if got := windowMinutes(w.since(time.Time{})); !reflect.DeepEqual(c.want, got) {
+				t.Errorf("%s, want: %v, got: %v", c.name, c.want, got)
+			}
+		})
+	}
+}
+
+func TestWindow_Evict(t *testing.T) {
+	w := &window{}
+	for i := 0; i < 6; i++ {
+		w.push(windowTr(i, i))
+	}
+
+	if got := windowMinutes(w.evict(minute(2))); !reflect.DeepEqual([]int{0, 1}, got) {
+		t.Errorf("want: %v, got: %v", []int{0, 1}, got)
+	}
+	if got := windowMinutes(w.evict(minute(4))); !reflect.DeepEqual([]int{2, 3}, got) {
+		t.Errorf("want: %v, got: %v", []int{2, 3}, got)
+	}
+	if got := windowMinutes(w.evict(minute(4))); len(got) != 0 {
+		t.Errorf("want nothing evicted, got: %v", got)
+	}
+	if got := w.len(); got != 2 {
+		t.Errorf("want: %d, got: %d", 2, got)
+	}
+	if got := w.latest(); !got.Equal(minute(5)) {
+		t.Errorf("want: %v, got: %v", minute(5), got)
+	}
+
+	w.evict(minute(10))
+	if got := w.len(); got != 0 {
+		t.Errorf("want: %d, got: %d", 0, got)
+	}
+	if got := w.latest(); !got.IsZero() {
+		t.Errorf("want zero datetime, got: %v", got)
+	}
+}
+
+func TestWindow_Since(t *testing.T) {
+	w := &window{}
+	for i := 0; i < 5; i++ {
+		w.push(windowTr(i, i))
+	}
+
+	cases := []struct {
+		name string
+		in   time.Time
+		want []int
+	}{
+		{"before all", minute(-1), []int{0, 1, 2, 3, 4}},
+		{"inclusive", minute(3), []int{3, 4}},
+		{"between", minute(3).Add(time.Second), []int{4}},
+		{"after all", minute(5), []int{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := windowMinutes(w.since(c.in)); !reflect.DeepEqual(c.want, got) {
+				t.Errorf("%s, want: %v, got: %v", c.name, c.want, got)
+			}
+		})
+	}
+}
+
+func TestWindow_Between(t *testing.T) {
+	w := &window{}
+	for i := 0; i < 5; i++ {
+		w.push(windowTr(i, i))
+	}
+
+	cases := []struct {
+		name         string
+		since, until time.Time
+		want         []int
+	}{
+		{"all", minute(-1), minute(5), []int{0, 1, 2, 3, 4}},
+		{"inclusive", minute(1), minute(3), []int{1, 2, 3}},
+		{"before all", minute(-2), minute(-1), []int{}},
+		{"empty interval", minute(2).Add(time.Second), minute(3).Add(-time.Second), []int{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := windowMinutes(w.between(c.since, c.until)); !reflect.DeepEqual(c.want, got) {
+				t.Errorf("%s, want: %v, got: %v", c.name, c.want, got)
+			}
+		})
+	}
+}
+
+func TestTimeline_Window(t *testing.T) {
+	timeline := NewTimeline()
+	timeline.Process(Event{Account: &Account{ActiveCard: true, AvailableLimit: 1000}})
+	for i, m := range []string{"Arizona Coyotes", "San Jose Sharks", "Arizona Coyotes", "San Jose Sharks"} {
+		timeline.Process(Event{Transaction: &Transaction{Merchant: m, Amount: 10, Time: datetime(minute(2 * i))}})
+	}
+
+	if got := windowMinutes(timeline.Window(time.Time{}, minute(6))); !reflect.DeepEqual([]int{4, 6}, got) {
+		t.Errorf("want: %v, got: %v", []int{4, 6}, got)
+	}
+	if got := windowMinutes(timeline.MerchantWindow("San Jose Sharks", minute(5), minute(6))); !reflect.DeepEqual([]int{6}, got) {
+		t.Errorf("want: %v, got: %v", []int{6}, got)
+	}
+	if got := timeline.MerchantWindow("Seattle Kraken", time.Time{}, minute(6)); len(got) != 0 {
+		t.Errorf("want nothing, got: %v", got)
+	}
+	if got := len(timeline.byMerchant); got != 2 {
+		t.Errorf("want: %d merchants, got: %d", 2, got)
+	}
+}
+
+// TestTimeline_Process_Evicted pins down the velocity rules for Transaction older than the latest valid one by more
+// than the largest window: the Transaction they would be counted against were already evicted, so they are approved.
+// Scanning the whole history, as before the windows, rejected the late one below.
+func TestTimeline_Process_Evicted(t *testing.T) {
+	timeline := NewTimeline()
+	timeline.Process(Event{Account: &Account{ActiveCard: true, AvailableLimit: 1000}})
+	for _, s := range []int{0, 30, 60, 540} {
+		merchant := fmt.Sprintf("merchant-%d", s)
+		timeline.Process(Event{Transaction: &Transaction{Merchant: merchant, Amount: 10, Time: datetime(second(s))}})
+	}
+
+	timeline.Process(Event{Transaction: &Transaction{Merchant: "merchant-0", Amount: 10, Time: datetime(second(90))}})
+	if got := timeline.Last().Violations; len(got) != 0 {
+		t.Errorf("want no violations, got: %v", got)
+	}
+	if got := windowMinutes(timeline.Window(time.Time{}, minute(9))); !reflect.DeepEqual([]int{9}, got) {
+		t.Errorf("want: %v, got: %v", []int{9}, got)
+	}
+}
+
+func windowTr(i, m int) Transaction {
+	return Transaction{Merchant: "Chicago Blackhawks", Amount: i, Time: datetime(minute(m))}
+}
+
+func minute(m int) time.Time {
+	return time.Time(trTime).Add(time.Duration(m) * time.Minute)
+}
+
+func second(s int) time.Time {
+	return time.Time(trTime).Add(time.Duration(s) * time.Second)
+}
+
+func windowMinutes(trs []Transaction) []int {
+	minutes := make([]int, 0, len(trs))
+	for _, tr := range trs {
+		minutes = append(minutes, int(time.Time(tr.Time).Sub(time.Time(trTime)).Minutes()))
+	}
+
+	return minutes
+}